@@ -0,0 +1,14 @@
+package errproxy
+
+import "fmt"
+
+// Annotate wraps err with the name of the type and method it came from, using a fmt.Errorf format that
+// receives the type name, method name and error in that order.  Generated wrappers call this when
+// annotation is turned on in the generation config.  Nil errors are returned without allocating.
+func Annotate(err error, format string, typeName string, method string) error {
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf(format, typeName, method, err)
+}
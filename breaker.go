@@ -0,0 +1,239 @@
+package errproxy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned through the transformer in place of calling the wrapped method while the
+// circuit breaker is open
+var ErrCircuitOpen = errors.New("errproxy: circuit breaker is open")
+
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = BreakerState(iota) // Calls go through
+	BreakerOpen                                       // Calls fail immediately with ErrCircuitOpen
+	BreakerHalfOpen                                   // A single probe call is let through to test the waters
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+
+	return "unknown"
+}
+
+// Defaults used for the BreakerOptions fields that aren't set
+const (
+	DefaultMinCalls = 10
+	DefaultWindow   = time.Minute
+	DefaultCooldown = 30 * time.Second
+)
+
+type BreakerOptions struct {
+	// FailureRate is the fraction of failed calls, from 0 to 1, within a window that trips the breaker.
+	// Defaults to 0.5.
+	FailureRate float64
+	// MinCalls is the number of calls a window needs before the failure rate is considered, so a
+	// handful of errors can't trip the breaker on their own.  Defaults to DefaultMinCalls.
+	MinCalls int
+	// Window is how long failures are counted for before the counts reset.  Defaults to DefaultWindow.
+	Window time.Duration
+	// Cooldown is how long the breaker stays open before letting a probe call through.  A probe that
+	// hasn't finished within the cooldown, such as one that panicked, is given up on and another probe
+	// is let through.  Defaults to DefaultCooldown.
+	Cooldown time.Duration
+	// IsFailure classifies errors that count against the breaker.  By default, every error counts
+	// aside from context cancellation.
+	IsFailure func(err error) bool
+}
+
+// CircuitBreaker short-circuits calls through a wrapper tree while the wrapped service is failing.
+// Set it on the root Proxy and every child wrapper will share it.  Only methods that return an error
+// are affected, and cleanup methods like Close and Rollback are always let through and never counted.
+type CircuitBreaker struct {
+	options BreakerOptions
+
+	lock         sync.Mutex
+	state        BreakerState
+	windowStart  time.Time
+	openedAt     time.Time
+	calls        int
+	failures     int
+	probing      bool
+	probeStarted time.Time
+}
+
+func NewCircuitBreaker(options BreakerOptions) *CircuitBreaker {
+	if options.FailureRate <= 0 {
+		options.FailureRate = 0.5
+	}
+
+	if options.MinCalls <= 0 {
+		options.MinCalls = DefaultMinCalls
+	}
+
+	if options.Window <= 0 {
+		options.Window = DefaultWindow
+	}
+
+	if options.Cooldown <= 0 {
+		options.Cooldown = DefaultCooldown
+	}
+
+	if options.IsFailure == nil {
+		options.IsFailure = func(err error) bool {
+			return !errors.Is(err, context.Canceled)
+		}
+	}
+
+	return &CircuitBreaker{
+		options:     options,
+		windowStart: time.Now(),
+	}
+}
+
+// State returns the breaker's current state, for use in health checks
+func (b *CircuitBreaker) State() BreakerState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.options.Cooldown {
+		return BreakerHalfOpen
+	}
+
+	return b.state
+}
+
+// Allow returns ErrCircuitOpen if a call should not go through
+func (b *CircuitBreaker) Allow() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.options.Cooldown {
+			return ErrCircuitOpen
+		}
+
+		b.state = BreakerHalfOpen
+		b.startProbe()
+		return nil
+	case BreakerHalfOpen:
+		// A probe that never reported back, because it panicked or hung, shouldn't hold the breaker
+		// half-open forever
+		if b.probing && time.Since(b.probeStarted) < b.options.Cooldown {
+			return ErrCircuitOpen
+		}
+
+		b.startProbe()
+		return nil
+	}
+
+	return nil
+}
+
+// Record counts the outcome of a call that Allow let through
+func (b *CircuitBreaker) Record(err error) {
+	failed := err != nil && b.options.IsFailure(err)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := time.Now()
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+		if failed {
+			b.trip(now)
+		} else {
+			b.reset(now, BreakerClosed)
+		}
+		return
+	}
+
+	if b.state != BreakerClosed {
+		return
+	}
+
+	if now.Sub(b.windowStart) >= b.options.Window {
+		b.reset(now, BreakerClosed)
+	}
+
+	b.calls++
+	if failed {
+		b.failures++
+	}
+
+	if b.calls >= b.options.MinCalls && float64(b.failures)/float64(b.calls) >= b.options.FailureRate {
+		b.trip(now)
+	}
+}
+
+func (b *CircuitBreaker) startProbe() {
+	b.probing = true
+	b.probeStarted = time.Now()
+}
+
+func (b *CircuitBreaker) trip(now time.Time) {
+	b.state = BreakerOpen
+	b.openedAt = now
+}
+
+func (b *CircuitBreaker) reset(now time.Time, state BreakerState) {
+	b.state = state
+	b.windowStart = now
+	b.calls = 0
+	b.failures = 0
+}
+
+// Allow is called by generated wrappers before calling the wrapped method.  A non-nil error is
+// returned through the transformer in place of calling the method, or set on a new command for
+// CarriesError methods.  Other methods that don't return an error skip the circuit breaker, panic if the
+// MethodPolicy denies them, and can only be affected by latency and panics from the FaultInjector.
+func (p *Proxy) Allow(method *Method) error {
+	if p == nil {
+		return nil
+	}
+
+	// Denied calls don't count against the breaker, since the service was never called
+	err := p.checkPolicy(method)
+	if err != nil {
+		return err
+	}
+
+	if p.Breaker != nil && method.ReturnsError && !method.Cleanup {
+		err = p.Breaker.Allow()
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.Faults != nil && !method.RecoversPanics {
+		// Injected errors count against the breaker just like real ones
+		err = p.Faults.Inject(method)
+		if err != nil {
+			p.Record(method, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Record is called by generated wrappers with the error from a method that Allow let through
+func (p *Proxy) Record(method *Method, err error) {
+	if p == nil || p.Breaker == nil || !method.ReturnsError || method.Cleanup {
+		return
+	}
+
+	p.Breaker.Record(err)
+}
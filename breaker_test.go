@@ -0,0 +1,176 @@
+package errproxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errBoom = errors.New("boom")
+
+func TestCircuitBreakerTrips(t *testing.T) {
+	testCases := []struct {
+		name     string
+		options  BreakerOptions
+		outcomes []error
+		expected BreakerState
+	}{
+		{
+			name:     "DefaultMinCallsIgnoresSingleFailure",
+			options:  BreakerOptions{Cooldown: time.Minute},
+			outcomes: []error{errBoom},
+			expected: BreakerClosed,
+		},
+		{
+			name:     "DefaultMinCallsTrips",
+			options:  BreakerOptions{Cooldown: time.Minute},
+			outcomes: []error{errBoom, errBoom, errBoom, errBoom, errBoom, nil, nil, nil, nil, errBoom},
+			expected: BreakerOpen,
+		},
+		{
+			name:     "BelowFailureRate",
+			options:  BreakerOptions{MinCalls: 4, FailureRate: 0.5, Cooldown: time.Minute},
+			outcomes: []error{errBoom, nil, nil, nil},
+			expected: BreakerClosed,
+		},
+		{
+			name:     "AtFailureRate",
+			options:  BreakerOptions{MinCalls: 4, FailureRate: 0.5, Cooldown: time.Minute},
+			outcomes: []error{errBoom, nil, errBoom, nil},
+			expected: BreakerOpen,
+		},
+		{
+			name:     "DefaultCooldownStaysOpen",
+			options:  BreakerOptions{MinCalls: 2},
+			outcomes: []error{errBoom, errBoom},
+			expected: BreakerOpen,
+		},
+		{
+			name:     "CancellationIsNotFailure",
+			options:  BreakerOptions{MinCalls: 2, Cooldown: time.Minute},
+			outcomes: []error{context.Canceled, context.Canceled},
+			expected: BreakerClosed,
+		},
+		{
+			name: "CustomIsFailure",
+			options: BreakerOptions{MinCalls: 2, Cooldown: time.Minute, IsFailure: func(err error) bool {
+				return err == errBoom
+			}},
+			outcomes: []error{errBoom, errors.New("not found")},
+			expected: BreakerOpen,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			breaker := NewCircuitBreaker(testCase.options)
+			for _, outcome := range testCase.outcomes {
+				if err := breaker.Allow(); err != nil {
+					t.Fatalf("unexpected Allow error: %v", err)
+				}
+				breaker.Record(outcome)
+			}
+
+			if state := breaker.State(); state != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, state)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerWindowResets(t *testing.T) {
+	breaker := NewCircuitBreaker(BreakerOptions{MinCalls: 2, Window: 10 * time.Millisecond})
+	breaker.Record(errBoom)
+	time.Sleep(20 * time.Millisecond)
+	breaker.Record(errBoom)
+
+	if state := breaker.State(); state != BreakerClosed {
+		t.Errorf("expected failures from an old window to be forgotten, got %s", state)
+	}
+}
+
+func tripBreaker(t *testing.T, cooldown time.Duration) *CircuitBreaker {
+	t.Helper()
+
+	breaker := NewCircuitBreaker(BreakerOptions{MinCalls: 1, Cooldown: cooldown})
+	breaker.Record(errBoom)
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen once tripped, got %v", err)
+	}
+
+	return breaker
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	testCases := []struct {
+		name     string
+		probe    error
+		expected BreakerState
+	}{
+		{name: "SuccessCloses", probe: nil, expected: BreakerClosed},
+		{name: "FailureReopens", probe: errBoom, expected: BreakerOpen},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			breaker := tripBreaker(t, 10*time.Millisecond)
+			time.Sleep(20 * time.Millisecond)
+
+			if err := breaker.Allow(); err != nil {
+				t.Fatalf("expected the probe to be let through, got %v", err)
+			}
+
+			if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("expected a second call during the probe to fail, got %v", err)
+			}
+
+			breaker.Record(testCase.probe)
+			if state := breaker.State(); state != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, state)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerAbandonedProbe(t *testing.T) {
+	breaker := tripBreaker(t, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	// The probe panics, so Record is never called
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("expected the probe to be let through, got %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("expected a new probe once the first timed out, got %v", err)
+	}
+
+	breaker.Record(nil)
+	if state := breaker.State(); state != BreakerClosed {
+		t.Errorf("expected closed, got %s", state)
+	}
+}
+
+func TestProxyAllowSkipsCleanup(t *testing.T) {
+	proxy := &Proxy{Breaker: tripBreaker(t, time.Minute)}
+
+	testCases := []struct {
+		method   *Method
+		expected error
+	}{
+		{method: &Method{Name: "Query", ReturnsError: true}, expected: ErrCircuitOpen},
+		{method: &Method{Name: "Close", ReturnsError: true, Cleanup: true}, expected: nil},
+		{method: &Method{Name: "Rollback", ReturnsError: true, Cleanup: true}, expected: nil},
+		{method: &Method{Name: "Stats"}, expected: nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.method.Name, func(t *testing.T) {
+			if err := proxy.Allow(testCase.method); !errors.Is(err, testCase.expected) || (err != nil) != (testCase.expected != nil) {
+				t.Errorf("expected %v, got %v", testCase.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/CannibalVox/errproxy"
+)
+
+type TransformerOrder string
+
+const (
+	TransformBefore TransformerOrder = "before" // Run the method transformer before the instance transformer
+	TransformAfter  TransformerOrder = "after"  // Run the method transformer on the output of the instance transformer
+)
+
+// MethodTransformer maps wrapped methods to a transformer function that should be called for
+// them alongside the transformer the wrapper was created with
+type MethodTransformer struct {
+	// Pattern is a Type.Method pattern, such as "Client.Get" or "*.Exec*".  See errproxy.MatchMethod.
+	Pattern string `json:"pattern"`
+	// Transformer is the func(error) error to call, either fully qualified like
+	// "github.com/me/app/errmap.RedisNil" or a bare name for a function in the output package
+	Transformer string           `json:"transformer"`
+	Order       TransformerOrder `json:"order,omitempty"`
+}
+
+// QualifiedFunc returns the package path and name of the transformer function.  The package path
+// is empty for functions in the output package.
+func (m MethodTransformer) QualifiedFunc() (pkgPath string, name string) {
+	lastDot := strings.LastIndex(m.Transformer, ".")
+	if lastDot < 0 || lastDot < strings.LastIndex(m.Transformer, "/") {
+		return "", m.Transformer
+	}
+
+	return m.Transformer[:lastDot], m.Transformer[lastDot+1:]
+}
+
+// Duration is a time.Duration that's written as a string, like "2s", in the config
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(text []byte) error {
+	var durationText string
+	err := json.Unmarshal(text, &durationText)
+	if err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(durationText)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(duration)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// MethodTimeout sets a default timeout for context-taking methods, which is applied when the caller's
+// ctx has no deadline
+type MethodTimeout struct {
+	Pattern string   `json:"pattern"`
+	Timeout Duration `json:"timeout"`
+}
+
+// DefaultAnnotateFormat produces errors like "redis.Client.Get: connection refused"
+const DefaultAnnotateFormat = "%s.%s: %w"
+
+// Config holds generation options that are too involved to pass as flags
+type Config struct {
+	MethodTransformers []MethodTransformer `json:"methodTransformers,omitempty"`
+
+	// Annotate wraps every error returned from a wrapped method with the wrapped type and method
+	// name before any transformers see it.  AnnotateFormat is passed to fmt.Errorf with the type name,
+	// method name and error, in that order, and must wrap the error with %w.
+	Annotate       bool   `json:"annotate,omitempty"`
+	AnnotateFormat string `json:"annotateFormat,omitempty"`
+
+	// CaptureStacks attaches the stack of the wrapped method's caller to transformed errors.  Depth and
+	// sampling are controlled at runtime with errproxy.SetStackOptions.
+	CaptureStacks bool `json:"captureStacks,omitempty"`
+
+	// RecoverPanics turns panics from the inner call into an *errproxy.PanicError, which is returned
+	// through the transformer.  Only methods whose last result is an error recover, everything else
+	// panics as usual.
+	RecoverPanics bool `json:"recoverPanics,omitempty"`
+
+	// Idempotent lists method patterns that are safe to call more than once.  Wrappers retry these
+	// methods according to the Proxy's RetryPolicy when they return an error.
+	Idempotent []string `json:"idempotent,omitempty"`
+
+	// Timeouts sets default timeouts for methods whose first parameter is a context.Context, unless
+	// they return something that keeps using it, like *sql.Rows.  The first matching pattern wins.
+	Timeouts []MethodTimeout `json:"timeouts,omitempty"`
+
+	// TrackLeaks makes wrappers of types with a Close() error method register with the Proxy's
+	// LeakTracker when they're created, and unregister when they're closed
+	TrackLeaks bool `json:"trackLeaks,omitempty"`
+
+	// AnonymousNames overrides the names of wrappers for anonymous types, keyed by the method that returns
+	// them, such as {"Client.Pipeline": "Pipeline"}.  Without an override, they're named after the first
+	// method that returns them, such as ClientPipelineResult.
+	AnonymousNames map[string]string `json:"anonymousNames,omitempty"`
+
+	// LicenseHeader is the path of a text/template file, relative to the config file, rendered at the top
+	// of every generated file.  It's executed with a filegen.Header, and lines that aren't already comments
+	// are commented out.
+	LicenseHeader string `json:"licenseHeader,omitempty"`
+	// LicenseTemplate is the parsed LicenseHeader
+	LicenseTemplate *template.Template `json:"-"`
+}
+
+func Load(configPath string) (*Config, error) {
+	cfg := &Config{}
+	if configPath == "" {
+		return cfg, cfg.validate()
+	}
+
+	text, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(text, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config %s: %w", configPath, err)
+	}
+
+	if cfg.LicenseHeader != "" {
+		licensePath := cfg.LicenseHeader
+		if !filepath.IsAbs(licensePath) {
+			licensePath = filepath.Join(filepath.Dir(configPath), licensePath)
+		}
+
+		cfg.LicenseTemplate, err = template.ParseFiles(licensePath)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse license header: %w", err)
+		}
+	}
+
+	return cfg, cfg.validate()
+}
+
+func (c *Config) validate() error {
+	if c.AnnotateFormat == "" {
+		c.AnnotateFormat = DefaultAnnotateFormat
+	} else if !strings.Contains(c.AnnotateFormat, "%w") && !strings.Contains(c.AnnotateFormat, "%[3]w") {
+		return fmt.Errorf("annotateFormat '%s' must wrap the error with %%w", c.AnnotateFormat)
+	}
+
+	for _, pattern := range c.Idempotent {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid idempotent pattern '%s': %w", pattern, err)
+		}
+	}
+
+	for _, timeout := range c.Timeouts {
+		if _, err := path.Match(timeout.Pattern, ""); err != nil {
+			return fmt.Errorf("invalid timeout pattern '%s': %w", timeout.Pattern, err)
+		}
+
+		if timeout.Timeout <= 0 {
+			return fmt.Errorf("timeout pattern '%s' needs a positive timeout", timeout.Pattern)
+		}
+	}
+
+	for _, transformer := range c.MethodTransformers {
+		if _, err := path.Match(transformer.Pattern, ""); err != nil {
+			return fmt.Errorf("invalid method pattern '%s': %w", transformer.Pattern, err)
+		}
+
+		if transformer.Transformer == "" {
+			return fmt.Errorf("method pattern '%s' has no transformer", transformer.Pattern)
+		}
+
+		switch transformer.Order {
+		case "", TransformBefore, TransformAfter:
+		default:
+			return fmt.Errorf("method pattern '%s' has unknown order '%s'", transformer.Pattern, transformer.Order)
+		}
+	}
+
+	return nil
+}
+
+// IsIdempotent reports whether a method matches any of the idempotent patterns
+func (c *Config) IsIdempotent(typeName string, methodName string) bool {
+	for _, pattern := range c.Idempotent {
+		if errproxy.MatchMethod(pattern, typeName, methodName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TimeoutForMethod returns the default timeout for a method, or 0 if it has none
+func (c *Config) TimeoutForMethod(typeName string, methodName string) time.Duration {
+	for _, timeout := range c.Timeouts {
+		if errproxy.MatchMethod(timeout.Pattern, typeName, methodName) {
+			return time.Duration(timeout.Timeout)
+		}
+	}
+
+	return 0
+}
+
+// TransformersForMethod returns the method transformers that apply to a method, split into those
+// that run before and after the instance transformer, in config order
+func (c *Config) TransformersForMethod(typeName string, methodName string) (before []MethodTransformer, after []MethodTransformer) {
+	for _, transformer := range c.MethodTransformers {
+		if !errproxy.MatchMethod(transformer.Pattern, typeName, methodName) {
+			continue
+		}
+
+		if transformer.Order == TransformAfter {
+			after = append(after, transformer)
+		} else {
+			before = append(before, transformer)
+		}
+	}
+
+	return before, after
+}
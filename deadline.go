@@ -0,0 +1,34 @@
+package errproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func noCancel() {}
+
+// WithDefaultTimeout applies timeout to ctx, unless ctx already has a deadline.  Generated wrappers call
+// it for context-taking methods that have a default timeout in the generation config.
+func WithDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		return ctx, noCancel
+	}
+
+	if _, hasDeadline := ctx.Deadline(); hasDeadline {
+		return ctx, noCancel
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// AnnotateDeadline wraps context.DeadlineExceeded errors with the type and method that ran out of
+// time, and returns every other error untouched
+func AnnotateDeadline(err error, typeName string, method string) error {
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	return fmt.Errorf("%s.%s: %w", typeName, method, err)
+}
@@ -0,0 +1,40 @@
+package errproxy
+
+// Transform runs err through the transformer.  Nil errors are returned without calling the
+// transformer, and a nil transformer returns the error untouched rather than panicking.
+func Transform(t ErrorTransformer, err error) error {
+	if err == nil || t == nil {
+		return err
+	}
+
+	return t(err)
+}
+
+// Do calls fn and transforms the error it returns.  It's meant for one-off calls into libraries
+// that aren't worth generating a full wrapper for, and accepts the same transformers as New.  Pass
+// proxy.Transform to use the transformer of an existing wrapper tree.  If fn panics, the panic is
+// recovered and returned through the transformer as a *PanicError.
+func Do(t ErrorTransformer, fn func() error) (err error) {
+	defer func() { err = Transform(t, err) }()
+	defer RecoverPanic(&err)
+
+	return fn()
+}
+
+// Do1 is Do for functions that return a value alongside their error.  The value is the zero value
+// if fn panics.
+func Do1[T any](t ErrorTransformer, fn func() (T, error)) (r0 T, err error) {
+	defer func() { err = Transform(t, err) }()
+	defer RecoverPanic(&err)
+
+	return fn()
+}
+
+// Do2 is Do for functions that return two values alongside their error.  The values are the zero
+// values if fn panics.
+func Do2[A, B any](t ErrorTransformer, fn func() (A, B, error)) (r0 A, r1 B, err error) {
+	defer func() { err = Transform(t, err) }()
+	defer RecoverPanic(&err)
+
+	return fn()
+}
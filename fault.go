@@ -0,0 +1,116 @@
+package errproxy
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ErrInjectedFault is returned by fault rules that don't specify their own error
+var ErrInjectedFault = errors.New("errproxy: injected fault")
+
+// FaultRule describes a fault to inject into calls to matching methods.  A rule can add latency and
+// then fail the call, either with an error or a panic.
+type FaultRule struct {
+	// Pattern selects the methods the rule applies to.  See MatchMethod.
+	Pattern string
+	// Probability is the chance, from 0 to 1, that a matching call is affected
+	Probability float64
+	// Latency is added before the call goes ahead or fails
+	Latency time.Duration
+	// Err is returned in place of calling the wrapped method.  It's only used for methods that return
+	// an error, or a command that carries one such as go-redis's *StringCmd.  Rules that set none of
+	// Latency, Err or Panic fail with ErrInjectedFault.
+	Err error
+	// Panic is a value to panic with in place of calling the wrapped method
+	Panic interface{}
+}
+
+// FaultInjector injects failures into calls made through a wrapper tree, for testing how callers cope
+// with an unreliable service.  Set it on the root Proxy and every child wrapper will share it.  Rules
+// can be added and cleared while the tree is in use.
+type FaultInjector struct {
+	lock  sync.Mutex
+	rules []FaultRule
+	rand  *rand.Rand
+}
+
+// NewFaultInjector creates a FaultInjector whose random choices are driven by seed, so a failing test
+// can be reproduced by making the same calls with the same seed
+func NewFaultInjector(seed uint64) *FaultInjector {
+	return &FaultInjector{
+		rand: rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+func (f *FaultInjector) AddRule(rule FaultRule) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.rules = append(f.rules, rule)
+}
+
+func (f *FaultInjector) Clear() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.rules = nil
+}
+
+// pick returns the first rule that matches the method and wins its roll of the dice
+func (f *FaultInjector) pick(method *Method) (FaultRule, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, rule := range f.rules {
+		if !method.Matches(rule.Pattern) {
+			continue
+		}
+
+		if rule.Probability >= 1 || f.rand.Float64() < rule.Probability {
+			return rule, true
+		}
+	}
+
+	return FaultRule{}, false
+}
+
+// Inject applies the first matching rule to a call.  It sleeps for the rule's latency, then panics or
+// returns the error the call should fail with.  Methods that neither return nor carry an error only
+// get latency and panics.
+func (f *FaultInjector) Inject(method *Method) error {
+	rule, matched := f.pick(method)
+	if !matched {
+		return nil
+	}
+
+	if rule.Latency > 0 {
+		time.Sleep(rule.Latency)
+	}
+
+	if rule.Panic != nil {
+		panic(rule.Panic)
+	}
+
+	if !method.ReturnsError && !method.CarriesError {
+		return nil
+	}
+
+	if rule.Err == nil && rule.Latency == 0 {
+		return ErrInjectedFault
+	}
+
+	return rule.Err
+}
+
+// Inject is called by generated wrappers that recover panics, inside the recovered call, so that
+// injected panics come back as a *PanicError like real ones.  Other wrappers have faults injected by
+// Allow.
+func (p *Proxy) Inject(method *Method) error {
+	if p == nil || p.Faults == nil {
+		return nil
+	}
+
+	return p.Faults.Inject(method)
+}
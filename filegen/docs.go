@@ -0,0 +1,146 @@
+package filegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	gotypes "go/types"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Docs maps the position of each method declaration in the loaded packages to its doc comment, so
+// that generated methods can carry the documentation of the methods they wrap
+type Docs map[token.Pos]string
+
+// IndexDocs collects the doc comments of every method and interface method in the packages.  The
+// packages must have been loaded with packages.NeedSyntax.
+func IndexDocs(pkgs []*packages.Package) Docs {
+	docs := make(Docs)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(node ast.Node) bool {
+				switch node := node.(type) {
+				case *ast.FuncDecl:
+					if node.Recv != nil && node.Doc != nil {
+						docs[node.Name.Pos()] = node.Doc.Text()
+					}
+					return false
+				case *ast.InterfaceType:
+					for _, field := range node.Methods.List {
+						if len(field.Names) > 0 && field.Doc != nil {
+							docs[field.Names[0].Pos()] = field.Doc.Text()
+						}
+					}
+				}
+
+				return true
+			})
+		}
+	})
+
+	return docs
+}
+
+// Lookup returns the doc comment for a declared object, or an empty string if it has none
+func (d Docs) Lookup(obj gotypes.Object) string {
+	if d == nil {
+		return ""
+	}
+
+	return d[obj.Pos()]
+}
+
+// addDocComment writes text as a // comment above the next declaration in the file
+func (f *FileCreate) addDocComment(text string) {
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if line == "" || strings.HasPrefix(line, "\t") {
+			f.jen.Comment("//" + line)
+		} else {
+			f.jen.Comment("// " + line)
+		}
+	}
+}
+
+// methodDoc is the doc comment for a wrapper method: the wrapped method's own documentation, including
+// any Deprecated paragraph, plus a note about the error being transformed
+func (f *FileCreate) methodDoc(sourceName string, methodInfo *gotypes.Selection, sig *gotypes.Signature) string {
+	name := methodInfo.Obj().Name()
+	original := f.docs.Lookup(methodInfo.Obj())
+
+	var note string
+	if lastResultIsError(sig) {
+		note = fmt.Sprintf("The error returned by %s.%s is passed through the Proxy's transformer.", sourceName, name)
+	}
+
+	original = qualifyDocLinks(original, methodInfo.Obj().Pkg().Name())
+
+	if original == "" {
+		return strings.TrimSpace(fmt.Sprintf("%s calls %s.%s.  %s", name, sourceName, name, note))
+	}
+
+	if note == "" {
+		return original
+	}
+
+	return original + "\n" + note
+}
+
+var docLink = regexp.MustCompile(`\[(\*?)([A-Z][A-Za-z0-9_]*(\.[A-Za-z0-9_]+)?)\]`)
+
+// qualifyDocLinks rewrites doc links like [DB.BeginTx] that refer to the wrapped package, so that they
+// still resolve from the generated package
+func qualifyDocLinks(text string, pkgName string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "[") && strings.Contains(line, "]:") {
+			continue
+		}
+
+		lines[i] = docLink.ReplaceAllString(line, "[${1}"+pkgName+".${2}]")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+var generatedName = regexp.MustCompile(`^[rep][0-9]+$`)
+
+// namedResults returns the result names to use for a wrapper method, or nil if the wrapped method's
+// results are unnamed or their names would collide with identifiers the generated body relies on
+func namedResults(sig *gotypes.Signature, receiverName string) []string {
+	reserved := map[string]bool{
+		receiverName: true,
+		"errproxy":   true,
+		"context":    true,
+		"time":       true,
+	}
+
+	qualifier := func(pkg *gotypes.Package) string {
+		reserved[pkg.Name()] = true
+		return pkg.Name()
+	}
+
+	for i := 0; i < sig.Params().Len(); i++ {
+		param := sig.Params().At(i)
+		reserved[paramName(param, i)] = true
+		gotypes.TypeString(param.Type(), qualifier)
+	}
+
+	for i := 0; i < sig.Results().Len(); i++ {
+		gotypes.TypeString(sig.Results().At(i).Type(), qualifier)
+	}
+
+	names := make([]string, 0, sig.Results().Len())
+	for i := 0; i < sig.Results().Len(); i++ {
+		name := sig.Results().At(i).Name()
+		if name == "" || name == "_" || reserved[name] || generatedName.MatchString(name) || strings.HasPrefix(name, "proxy") {
+			return nil
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
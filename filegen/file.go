@@ -1,11 +1,14 @@
 package filegen
 
 import (
+	"bytes"
 	"fmt"
 	gotypes "go/types"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/CannibalVox/errproxy/config"
 	"github.com/CannibalVox/errproxy/jenutils"
 	"github.com/CannibalVox/errproxy/types"
 	"github.com/dave/jennifer/jen"
@@ -19,37 +22,59 @@ func paramName(param *gotypes.Var, paramIndex int) string {
 	return param.Name()
 }
 
+const errproxyPkg = "github.com/CannibalVox/errproxy"
+
 type FileCreate struct {
 	jen      *jen.File
 	typeDB   *types.TypeDB
+	config   *config.Config
+	docs     Docs
 	fileName string
+	// proxyField is the name of the wrapper's Proxy field, which changes if the wrapped type has a
+	// Proxy method
+	proxyField string
 }
 
-func NewFile(pkgName string, t *types.TypeInfo, db *types.TypeDB) *FileCreate {
+func NewFile(pkgName string, t *types.TypeInfo, db *types.TypeDB, cfg *config.Config, docs Docs, header Header) (*FileCreate, error) {
 	fileCreate := &FileCreate{
 		jen:      jen.NewFile(strings.ToLower(pkgName)),
 		fileName: t.TypeId.TypeFileName(),
 		typeDB:   db,
+		config:   cfg,
+		docs:     docs,
 	}
 
-	fileCreate.jen.PackageComment("ErrProxy Generated File, DO NOT EDIT")
+	err := fileCreate.addHeader(header)
+	if err != nil {
+		return nil, err
+	}
 
 	// type [ElementTypeName] struct {
-	//   inner [ElementType]
-	//	 errorTransformer ErrorTransformer
+	//   Inner [ElementType]
+	//	 Proxy *errproxy.Proxy // ErrProxy if the type has a Proxy method
 	// }
 	innerType := t.TypeId.Type
 	if !t.RootType.HasDirectReceiver {
 		innerType = gotypes.NewPointer(innerType)
 	}
 	innerField := jenutils.Type(jen.Id("Inner"), innerType)
-	errTransformerField := jen.Id("ErrorTransformer").Qual("github.com/CannibalVox/errproxy", "ErrorTransformer")
+	fileCreate.proxyField, err = proxyFieldName(t.RootType.RootType)
+	if err != nil {
+		return nil, err
+	}
+	proxyField := jen.Id(fileCreate.proxyField).Op("*").Qual(errproxyPkg, "Proxy")
 
-	fileCreate.jen.Type().Id(t.RootType.RootType.WrapperTypeName()).Struct(innerField, errTransformerField)
+	fileCreate.addDocComment(fmt.Sprintf(
+		"%s wraps %s.  Errors returned by its methods are passed through %s, and values they return are wrapped with the same Proxy.",
+		t.RootType.RootType.WrapperTypeName(),
+		sourceDescription(t.RootType.RootType),
+		fileCreate.proxyField,
+	))
+	fileCreate.jen.Type().Id(t.RootType.RootType.WrapperTypeName()).Struct(innerField, proxyField)
 
 	fileCreate.jen.Line()
 
-	return fileCreate
+	return fileCreate, nil
 }
 
 func (f *FileCreate) requiresWrap(t gotypes.Type, minWrapStatus types.WrapStatus) (bool, *types.TypeInfo) {
@@ -84,20 +109,25 @@ func (f *FileCreate) addWrappedType(stmt *jen.Statement, t gotypes.Type) jen.Cod
 }
 
 func (f *FileCreate) AppendType(t *types.TypeInfo) {
-	// func Wrap[ElementTypeName](inner [ElementType], errorTransformer ErrorTransformer) *[ElementTypeName] {
+	// func Wrap[ElementTypeName](inner [ElementType], proxy *errproxy.Proxy) *[ElementTypeName] {
 	// return &[ElementTypeName]{
-	//    inner: inner,
-	//    errorTransformer: errorTransformer,
+	//    Inner: inner,
+	//    Proxy: proxy,
 	//  }
 	//}
 
 	// Signature
 	innerField := jenutils.Type(jen.Id("inner"), t.TypeId.Type)
-	errTransformerField := jen.Id("errorTransformer").Qual("github.com/CannibalVox/errproxy", "ErrorTransformer")
+	proxyField := jen.Id("proxy").Op("*").Qual(errproxyPkg, "Proxy")
+	f.addDocComment(fmt.Sprintf(
+		"%s wraps %s so that errors returned by its methods are passed through proxy.",
+		t.TypeId.WrapFuncName(),
+		sourceDescription(t.TypeId),
+	))
 	funcDeclaration :=
 		f.jen.Func().
 			Id(t.TypeId.WrapFuncName()).
-			Params(innerField, errTransformerField)
+			Params(innerField, proxyField)
 
 	f.addWrappedType(funcDeclaration, t.TypeId.Type)
 	// End Signature
@@ -122,14 +152,20 @@ func (f *FileCreate) AppendType(t *types.TypeInfo) {
 			structAssign = jen.Op("&")
 		}
 
+		// proxy.TrackOpen("StructType", inner)
+		if f.tracksLeaks(t) {
+			g.Id("proxy").Dot("TrackOpen").Call(jen.Lit(t.TypeId.WrapperTypeName()), jen.Id("inner"))
+			g.Line()
+		}
+
 		// return &StructType {
-		// 	inner: *inner,
-		//  errorTransformer: errorTransformer,
+		// 	Inner: *inner,
+		//  Proxy: proxy,
 		// }
 		g.Return(structAssign.Id(t.TypeId.WrapperTypeName()).Values(
 			jen.DictFunc(func(d jen.Dict) {
 				d[jen.Id("Inner")] = innerAssign.Id("inner")
-				d[jen.Id("ErrorTransformer")] = jen.Id("errorTransformer")
+				d[jen.Id(f.proxyField)] = jen.Id("proxy")
 			}),
 		))
 	})
@@ -154,14 +190,28 @@ func (f *FileCreate) wrapMethod(t *types.TypeInfo, methodInfo *gotypes.Selection
 		return
 	}
 
+	// var method[ElementTypeName][Method Name] = &errproxy.Method{...}
+	//
 	//func (s [ElementTypeName]) [Method Signature] {
+	// proxyCall := s.Proxy.Intercept(method[ElementTypeName][Method Name])
+	// if proxyCall != nil {
+	//   proxyCall.Before(p0, p1)
+	// }
+	//
 	// r0, r1 := s.inner.[Method Name]([Params])
-	// return r0, r1
+	// e1 := s.Proxy.Transform(r1)
+	// if proxyCall != nil {
+	//   proxyCall.After(r1, e1, r0, r1)
+	// }
+	//
+	// return r0, e1
 	//}
 	params := []jen.Code{}
+	paramVals := []jen.Code{}
 	for i := 0; i < sig.Params().Len(); i++ {
 		param := sig.Params().At(i)
 
+		paramVals = append(paramVals, jen.Id(paramName(param, i)))
 		paramName := jen.Id(paramName(param, i))
 		if sig.Variadic() && i == sig.Params().Len()-1 {
 			paramSlice := param.Type().(*gotypes.Slice)
@@ -171,12 +221,6 @@ func (f *FileCreate) wrapMethod(t *types.TypeInfo, methodInfo *gotypes.Selection
 		}
 	}
 
-	retVal := []jen.Code{}
-	for i := 0; i < sig.Results().Len(); i++ {
-		result := sig.Results().At(i)
-		retVal = append(retVal, f.addWrappedType(jen.Null(), result.Type()))
-	}
-
 	// Method signature
 	receiverName := methodInfo.Type().(*gotypes.Signature).Recv().Name()
 	if receiverName == "" {
@@ -184,6 +228,32 @@ func (f *FileCreate) wrapMethod(t *types.TypeInfo, methodInfo *gotypes.Selection
 		receiverName = fmt.Sprintf("iFace%s", t.TypeId.WrapperTypeName())
 	}
 
+	resultNames := namedResults(sig, receiverName)
+	retVal := []jen.Code{}
+	for i := 0; i < sig.Results().Len(); i++ {
+		result := sig.Results().At(i)
+		resultName := jen.Null()
+		if resultNames != nil {
+			resultName = jen.Id(resultNames[i])
+		}
+		retVal = append(retVal, f.addWrappedType(resultName, result.Type()))
+	}
+
+	// Method descriptor
+	methodDesc := fmt.Sprintf("method%s%s", t.TypeId.WrapperTypeName(), methodInfo.Obj().Name())
+	f.jen.Var().Id(methodDesc).Op("=").Op("&").Qual(errproxyPkg, "Method").Values(jen.Dict{
+		jen.Id("Package"):        jen.Lit(t.TypeId.SourcePackagePath()),
+		jen.Id("Type"):           jen.Lit(t.TypeId.QualifiedSourceName()),
+		jen.Id("Wrapper"):        jen.Lit(t.TypeId.WrapperTypeName()),
+		jen.Id("Name"):           jen.Lit(methodInfo.Obj().Name()),
+		jen.Id("ReturnsError"):   jen.Lit(lastResultIsError(sig)),
+		jen.Id("Cleanup"):        jen.Lit(isCleanup(methodInfo.Obj().Name(), sig)),
+		jen.Id("CarriesError"):   jen.Lit(carriesError(sig)),
+		jen.Id("RecoversPanics"): jen.Lit(f.config.RecoverPanics && lastResultIsError(sig)),
+	})
+	f.jen.Line()
+
+	f.addDocComment(f.methodDoc(t.TypeId.QualifiedSourceName(), methodInfo, sig))
 	receiverType := jen.Id(t.TypeId.WrapperTypeName())
 	if t.TypeId.PointerDepth > 0 {
 		receiverType = jen.Op("*").Add(receiverType)
@@ -198,22 +268,23 @@ func (f *FileCreate) wrapMethod(t *types.TypeInfo, methodInfo *gotypes.Selection
 	}
 
 	wrappedMethod.BlockFunc(func(g *jen.Group) {
-		//r0, r1 := s.inner.[FuncName](p0, p1)
-		callLine := g.Null()
-
-		if len(retVal) > 0 {
-			for i := 0; i < sig.Results().Len(); i++ {
-				if i != 0 {
-					callLine.Op(",")
-				}
-
-				callLine.Id(fmt.Sprintf("r%d", i))
-			}
-
-			callLine.Op(":=")
+		//proxyCall := s.Proxy.Intercept(method[ElementTypeName][Method Name])
+		g.Id("proxyCall").Op(":=").Add(f.proxyOf(receiverName)).Dot("Intercept").Call(jen.Id(methodDesc))
+		g.If(jen.Id("proxyCall").Op("!=").Nil()).Block(
+			jen.Id("proxyCall").Dot("Before").Call(paramVals...),
+		)
+		g.Line()
+
+		//ctx, proxyCancel := errproxy.WithDefaultTimeout(ctx, [Timeout])
+		//defer proxyCancel()
+		if timeout := f.defaultTimeout(t, methodInfo); timeout > 0 {
+			ctxName := paramName(sig.Params().At(0), 0)
+			g.List(jen.Id(ctxName), jen.Id("proxyCancel")).Op(":=").Qual(errproxyPkg, "WithDefaultTimeout").Call(jen.Id(ctxName), durationCode(timeout))
+			g.Defer().Id("proxyCancel").Call()
+			g.Line()
 		}
 
-		callLine.Id(receiverName).Dot("Inner").Dot(methodInfo.Obj().Name()).CallFunc(func(g *jen.Group) {
+		var innerCall jen.Code = jen.Id(receiverName).Dot("Inner").Dot(methodInfo.Obj().Name()).CallFunc(func(g *jen.Group) {
 			for i := 0; i < sig.Params().Len(); i++ {
 				param := sig.Params().At(i)
 
@@ -235,21 +306,113 @@ func (f *FileCreate) wrapMethod(t *types.TypeInfo, methodInfo *gotypes.Selection
 			}
 		})
 
+		results := []jen.Code{}
+		for i := 0; i < sig.Results().Len(); i++ {
+			results = append(results, jen.Id(fmt.Sprintf("r%d", i)))
+		}
+
+		errResult := jen.Nil()
+		transformedResult := jen.Nil()
+		if carriesError(sig) {
+			// var r0 [ResultType]
+			// var proxyTransformed error
+			// proxyErr := s.Proxy.Allow(method[ElementTypeName][Method Name])
+			// if proxyErr == nil {
+			//   r0 = s.Inner.[FuncName](p0, p1)
+			// } else {
+			//   proxyTransformed = s.Proxy.Transform(proxyErr)
+			//   r0 = new([ResultElemType])
+			//   r0.SetErr(proxyTransformed)
+			// }
+			resultType := sig.Results().At(0).Type()
+			errResult = jen.Id("proxyErr")
+			transformedResult = jen.Id("proxyTransformed")
+			g.Var().Add(results[0]).Add(jenutils.Type(jen.Null(), resultType))
+			g.Var().Add(transformedResult).Error()
+			g.Add(errResult).Op(":=").Add(f.proxyOf(receiverName)).Dot("Allow").Call(jen.Id(methodDesc))
+			g.If(jen.Add(errResult).Op("==").Nil()).Block(
+				jen.Add(results[0]).Op("=").Add(innerCall),
+			).Else().Block(
+				jen.Add(transformedResult).Op("=").Add(f.transformError(t, methodInfo, receiverName, errResult)),
+				jen.Add(results[0]).Op("=").New(jenutils.Type(jen.Null(), resultType.(*gotypes.Pointer).Elem())),
+				jen.Add(results[0]).Dot("SetErr").Call(transformedResult),
+			)
+		} else if !lastResultIsError(sig) {
+			//s.Proxy.Allow(method[ElementTypeName][Method Name])
+			//r0 := s.Inner.[FuncName](p0, p1)
+			g.Add(f.proxyOf(receiverName)).Dot("Allow").Call(jen.Id(methodDesc))
+			callLine := g.Null()
+			if len(results) > 0 {
+				callLine.List(results...).Op(":=")
+			}
+			callLine.Add(innerCall)
+		} else {
+			// var r0 [ResultType]
+			// r1 := s.Proxy.Allow(method[ElementTypeName][Method Name])
+			// if r1 == nil {
+			//   r0, r1 = s.Inner.[FuncName](p0, p1)
+			//   s.Proxy.Record(method[ElementTypeName][Method Name], r1)
+			// }
+			if f.config.RecoverPanics {
+				innerCall = f.recoverPanics(sig, receiverName, methodDesc, innerCall)
+			}
+
+			errResult := results[len(results)-1]
+			for i := 0; i < sig.Results().Len()-1; i++ {
+				g.Var().Add(results[i]).Add(jenutils.Type(jen.Null(), sig.Results().At(i).Type()))
+			}
+			g.Add(errResult).Op(":=").Add(f.proxyOf(receiverName)).Dot("Allow").Call(jen.Id(methodDesc))
+			g.If(jen.Add(errResult).Op("==").Nil()).BlockFunc(func(g *jen.Group) {
+				g.List(results...).Op("=").Add(innerCall)
+				if f.config.IsIdempotent(t.TypeId.SourceTypeName(), methodInfo.Obj().Name()) {
+					g.Add(f.retryLoop(sig, receiverName, innerCall))
+				}
+				g.Add(f.proxyOf(receiverName)).Dot("Record").Call(jen.Id(methodDesc), errResult)
+				if methodInfo.Obj().Name() == "Close" && sig.Params().Len() == 0 && sig.Results().Len() == 1 && f.tracksLeaks(t) {
+					g.Add(f.proxyOf(receiverName)).Dot("TrackClose").Call(jen.Id(receiverName).Dot("Inner"))
+				}
+			})
+		}
+
+		//e1 := s.Proxy.Transform(r1)
+		for i := 0; i < sig.Results().Len(); i++ {
+			if types.IsError(sig.Results().At(i).Type()) {
+				errResult = jen.Id(fmt.Sprintf("r%d", i))
+				transformedResult = jen.Id(fmt.Sprintf("e%d", i))
+				g.Add(transformedResult).Op(":=").Add(f.transformError(t, methodInfo, receiverName, errResult))
+			}
+		}
+
+		//if proxyCall != nil { proxyCall.After(r1, e1, r0, r1) }
+		afterArgs := []jen.Code{errResult, transformedResult}
+		for i := 0; i < sig.Results().Len(); i++ {
+			afterArgs = append(afterArgs, jen.Id(fmt.Sprintf("r%d", i)))
+		}
+		g.If(jen.Id("proxyCall").Op("!=").Nil()).Block(
+			jen.Id("proxyCall").Dot("After").Call(afterArgs...),
+		)
+
 		if len(retVal) > 0 {
-			//return r0, r1
+			//return r0, e1
+			g.Line()
 			g.ReturnFunc(func(g *jen.Group) {
 				for i := 0; i < sig.Results().Len(); i++ {
 					result := sig.Results().At(i)
 					retVar := jen.Id(fmt.Sprintf("r%d", i))
 					doWrap, typeInfo := f.requiresWrap(result.Type(), types.WrapStatusSoft)
 
-					// If error, return s.errorTransformer(r0)
-					// If wrappable type, return WrapSomeType(r0)
+					// If error, return the transformed e0
+					// If wrappable type, return WrapSomeType(r0, s.Proxy.DeriveChild(proxyCall, "SomeType", "Method"))
 					// otherwise just return r0
 					if types.IsError(result.Type()) {
-						g.Id(receiverName).Dot("ErrorTransformer").Call(retVar)
+						g.Id(fmt.Sprintf("e%d", i))
 					} else if doWrap {
-						g.Id(typeInfo.TypeId.WrapFuncName()).Call(retVar, jen.Id(receiverName).Dot("ErrorTransformer"))
+						childProxy := f.proxyOf(receiverName).Dot("DeriveChild").Call(
+							jen.Id("proxyCall"),
+							jen.Lit(typeInfo.TypeId.WrapperTypeName()),
+							jen.Lit(methodInfo.Obj().Name()),
+						)
+						g.Id(typeInfo.TypeId.WrapFuncName()).Call(retVar, childProxy)
 					} else {
 						g.Add(retVar)
 					}
@@ -261,10 +424,223 @@ func (f *FileCreate) wrapMethod(t *types.TypeInfo, methodInfo *gotypes.Selection
 	f.jen.Line()
 }
 
+// proxyOf refers to the Proxy field of a wrapper
+func (f *FileCreate) proxyOf(receiverName string) *jen.Statement {
+	return jen.Id(receiverName).Dot(f.proxyField)
+}
+
+// proxyFieldName picks a name for the wrapper's Proxy field that doesn't collide with the methods it
+// wraps, since a struct can't have a field and a method with the same name
+func proxyFieldName(root types.TypeIdentifier) (string, error) {
+	methodSet := gotypes.NewMethodSet(root.Type)
+	if root.Mode != types.TypeInterface {
+		methodSet = gotypes.NewMethodSet(gotypes.NewPointer(root.Type))
+	}
+
+	for _, name := range []string{"Proxy", "ErrProxy", "WrapperProxy"} {
+		if methodSet.Lookup(nil, name) == nil {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s has Proxy, ErrProxy and WrapperProxy methods, so its wrapper has nowhere to keep its Proxy", root.QualifiedSourceName())
+}
+
+// sourceDescription names the wrapped type in generated doc comments
+func sourceDescription(t types.TypeIdentifier) string {
+	if t.SourcePackagePath() == "" {
+		if t.Mode == types.TypeInterface {
+			return "an anonymous interface"
+		}
+
+		return "an anonymous struct"
+	}
+
+	return gotypes.TypeString(t.Type, func(pkg *gotypes.Package) string {
+		return pkg.Name()
+	})
+}
+
+// tracksLeaks reports whether wrappers of this type should register with the leak tracker.  Only
+// wrappers that hold a pointer or interface can be tracked, since tracking is by identity.
+func (f *FileCreate) tracksLeaks(t *types.TypeInfo) bool {
+	if !f.config.TrackLeaks || !types.IsCloser(t.RootType.RootType.Type) {
+		return false
+	}
+
+	return t.TypeId.Mode == types.TypeInterface || (t.TypeId.PointerDepth == 1 && !t.RootType.HasDirectReceiver)
+}
+
+// defaultTimeout returns the timeout from the config for methods whose first parameter is a context,
+// or 0 if there isn't one.  The timeout is cancelled when the wrapper returns, so methods returning
+// results that keep using the context, like *sql.Rows or *sql.Tx, never get one.
+func (f *FileCreate) defaultTimeout(t *types.TypeInfo, methodInfo *gotypes.Selection) time.Duration {
+	sig := methodInfo.Type().(*gotypes.Signature)
+	if sig.Params().Len() == 0 || !types.IsContext(sig.Params().At(0).Type()) {
+		return 0
+	}
+
+	for i := 0; i < sig.Results().Len(); i++ {
+		if types.HoldsContext(sig.Results().At(i).Type()) {
+			return 0
+		}
+	}
+
+	return f.config.TimeoutForMethod(t.TypeId.SourceTypeName(), methodInfo.Obj().Name())
+}
+
+// durationCode renders a duration the way a person would write it, such as 2 * time.Second
+func durationCode(d time.Duration) jen.Code {
+	units := []time.Duration{time.Hour, time.Minute, time.Second, time.Millisecond, time.Microsecond}
+	unitNames := []string{"Hour", "Minute", "Second", "Millisecond", "Microsecond"}
+	for i, unit := range units {
+		if d%unit == 0 {
+			return jen.Lit(int(d/unit)).Op("*").Qual("time", unitNames[i])
+		}
+	}
+
+	return jen.Qual("time", "Duration").Call(jen.Lit(int(d)))
+}
+
+// isCleanup reports whether a method releases resources, like Close() error and Rollback() error
+func isCleanup(name string, sig *gotypes.Signature) bool {
+	if name != "Close" && name != "Rollback" {
+		return false
+	}
+
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 && lastResultIsError(sig)
+}
+
+// carriesError reports whether a method's only result is a command that holds its error, like
+// go-redis's *StringCmd, so failures can be reported by setting them on a new command
+func carriesError(sig *gotypes.Signature) bool {
+	return sig.Results().Len() == 1 && types.CarriesError(sig.Results().At(0).Type())
+}
+
+func lastResultIsError(sig *gotypes.Signature) bool {
+	return sig.Results().Len() > 0 && types.IsError(sig.Results().At(sig.Results().Len()-1).Type())
+}
+
+// retryLoop calls the inner method again for as long as the proxy's retry policy allows
+func (f *FileCreate) retryLoop(sig *gotypes.Signature, receiverName string, innerCall jen.Code) jen.Code {
+	// for proxyAttempt := 1; s.Proxy.ShouldRetry(ctx, proxyAttempt, r1); proxyAttempt++ {
+	//   r0, r1 = s.Inner.[FuncName](p0, p1)
+	// }
+	results := []jen.Code{}
+	for i := 0; i < sig.Results().Len(); i++ {
+		results = append(results, jen.Id(fmt.Sprintf("r%d", i)))
+	}
+
+	ctx := jen.Qual("context", "Background").Call()
+	if sig.Params().Len() > 0 && types.IsContext(sig.Params().At(0).Type()) {
+		ctx = jen.Id(paramName(sig.Params().At(0), 0))
+	}
+
+	return jen.For(
+		jen.Id("proxyAttempt").Op(":=").Lit(1),
+		f.proxyOf(receiverName).Dot("ShouldRetry").Call(ctx, jen.Id("proxyAttempt"), results[len(results)-1]),
+		jen.Id("proxyAttempt").Op("++"),
+	).Block(
+		jen.List(results...).Op("=").Add(innerCall),
+	)
+}
+
+// recoverPanics runs the inner call in a closure that turns panics into an error result.  Faults are
+// injected inside the closure, so injected panics are recovered like real ones.
+func (f *FileCreate) recoverPanics(sig *gotypes.Signature, receiverName string, methodDesc string, innerCall jen.Code) jen.Code {
+	// func() (r0 [ResultType], r1 error) {
+	//   defer errproxy.RecoverPanic(&r1)
+	//   if r1 = s.Proxy.Inject(method[ElementTypeName][Method Name]); r1 != nil {
+	//     return
+	//   }
+	//   return s.Inner.[FuncName](p0, p1)
+	// }()
+	results := []jen.Code{}
+	for i := 0; i < sig.Results().Len(); i++ {
+		results = append(results, jenutils.Type(jen.Id(fmt.Sprintf("r%d", i)), sig.Results().At(i).Type()))
+	}
+
+	errResult := jen.Id(fmt.Sprintf("r%d", sig.Results().Len()-1))
+
+	return jen.Func().Params().Params(results...).Block(
+		jen.Defer().Qual(errproxyPkg, "RecoverPanic").Call(jen.Op("&").Add(errResult)),
+		jen.If(
+			jen.Add(errResult).Op("=").Add(f.proxyOf(receiverName)).Dot("Inject").Call(jen.Id(methodDesc)),
+			jen.Add(errResult).Op("!=").Nil(),
+		).Block(jen.Return()),
+		jen.Return(innerCall),
+	).Call()
+}
+
+// transformError builds the expression that runs an error result through the wrapper's transformer,
+// along with any annotation, method transformers and stack capture from the config
+func (f *FileCreate) transformError(t *types.TypeInfo, methodInfo *gotypes.Selection, receiverName string, errVar jen.Code) jen.Code {
+	// errproxy.WithStack(errproxy.Transform(after, s.Proxy.Transform(errproxy.Transform(before, errproxy.Annotate(r0, ...)))))
+	before, after := f.config.TransformersForMethod(t.TypeId.SourceTypeName(), methodInfo.Obj().Name())
+
+	transformed := errVar
+	if f.defaultTimeout(t, methodInfo) > 0 && !f.config.Annotate {
+		transformed = jen.Qual(errproxyPkg, "AnnotateDeadline").Call(
+			transformed,
+			jen.Lit(t.TypeId.QualifiedSourceName()),
+			jen.Lit(methodInfo.Obj().Name()),
+		)
+	}
+
+	if f.config.Annotate {
+		transformed = jen.Qual(errproxyPkg, "Annotate").Call(
+			transformed,
+			jen.Lit(f.config.AnnotateFormat),
+			jen.Lit(t.TypeId.QualifiedSourceName()),
+			jen.Lit(methodInfo.Obj().Name()),
+		)
+	}
+
+	for _, methodTransformer := range before {
+		transformed = jen.Qual(errproxyPkg, "Transform").Call(methodTransformerFunc(methodTransformer), transformed)
+	}
+
+	transformed = f.proxyOf(receiverName).Dot("Transform").Call(transformed)
+
+	for _, methodTransformer := range after {
+		transformed = jen.Qual(errproxyPkg, "Transform").Call(methodTransformerFunc(methodTransformer), transformed)
+	}
+
+	if f.config.CaptureStacks {
+		transformed = jen.Qual(errproxyPkg, "WithStack").Call(transformed)
+	}
+
+	return transformed
+}
+
+func methodTransformerFunc(methodTransformer config.MethodTransformer) jen.Code {
+	pkgPath, funcName := methodTransformer.QualifiedFunc()
+	if pkgPath == "" {
+		return jen.Id(funcName)
+	}
+
+	return jen.Qual(pkgPath, funcName)
+}
+
 func (f *FileCreate) WriteFile(folder string) error {
 	return f.jen.Save(filepath.Join(folder, f.fileName))
 }
 
+// Render returns the formatted source of the file
+func (f *FileCreate) Render() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := f.jen.Render(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (f *FileCreate) FileName() string {
+	return f.fileName
+}
+
 func (f *FileCreate) String() string {
 	return f.fileName
 }
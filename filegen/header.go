@@ -0,0 +1,100 @@
+package filegen
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Header describes how a file was generated.  It's written at the top of every generated file, and
+// passed to the license header template from the config.
+type Header struct {
+	// GeneratorVersion is the module version of proxywrapper, or "(devel)"
+	GeneratorVersion string
+	// InputPackage is the import path of the package the wrapped type was loaded from
+	InputPackage string
+	// InputModule and InputVersion identify the module the input package belongs to.  They're empty
+	// for the standard library.
+	InputModule  string
+	InputVersion string
+	// Command is the proxywrapper command line
+	Command string
+	// Year is the year the file was generated, for copyright notices
+	Year int
+	// FileName is the name of the file being generated
+	FileName string
+}
+
+// GeneratedMarker is the standard generated code comment recognized by go tooling and linters
+const GeneratedMarker = "// Code generated by proxywrapper; DO NOT EDIT."
+
+// legacyMarker is the header written by earlier versions of proxywrapper
+const legacyMarker = "// ErrProxy Generated File, DO NOT EDIT"
+
+var generatedMarker = regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(GeneratedMarker) + `$`)
+var packageClause = regexp.MustCompile(`(?m)^package `)
+
+// IsGenerated reports whether a go file was written by proxywrapper, by either the current or the
+// legacy header
+func IsGenerated(text []byte) bool {
+	if bytes.HasPrefix(text, []byte(legacyMarker)) {
+		return true
+	}
+
+	// The marker must come before the package clause, but may follow a license header
+	loc := generatedMarker.FindIndex(text)
+	if loc == nil {
+		return false
+	}
+
+	return !packageClause.Match(text[:loc[0]])
+}
+
+func (h Header) inputDescription() string {
+	if h.InputModule == "" {
+		return fmt.Sprintf("%s (standard library)", h.InputPackage)
+	}
+
+	if h.InputVersion == "" {
+		return fmt.Sprintf("%s (module %s)", h.InputPackage, h.InputModule)
+	}
+
+	return fmt.Sprintf("%s (module %s %s)", h.InputPackage, h.InputModule, h.InputVersion)
+}
+
+// addHeader writes the license header from the config, if there is one, followed by the generated
+// code marker and a description of how the file was generated
+func (f *FileCreate) addHeader(header Header) error {
+	header.FileName = f.fileName
+	if header.Year == 0 {
+		header.Year = time.Now().Year()
+	}
+
+	if f.config.LicenseTemplate != nil {
+		license := new(strings.Builder)
+		err := f.config.LicenseTemplate.Execute(license, header)
+		if err != nil {
+			return fmt.Errorf("rendering license header for %s: %w", f.fileName, err)
+		}
+
+		for _, line := range strings.Split(strings.TrimRight(license.String(), "\n"), "\n") {
+			switch {
+			case strings.HasPrefix(line, "//"):
+				f.jen.HeaderComment(line)
+			case line == "":
+				f.jen.HeaderComment("//")
+			default:
+				f.jen.HeaderComment("// " + line)
+			}
+		}
+	}
+
+	f.jen.HeaderComment(GeneratedMarker)
+	f.jen.HeaderComment(fmt.Sprintf("// proxywrapper %s", header.GeneratorVersion))
+	f.jen.HeaderComment(fmt.Sprintf("// input: %s", header.inputDescription()))
+	f.jen.HeaderComment(fmt.Sprintf("// command: %s", header.Command))
+
+	return nil
+}
@@ -1,8 +1,13 @@
 module github.com/CannibalVox/errproxy
 
-go 1.15
+go 1.22.0
 
 require (
 	github.com/dave/jennifer v1.4.1
-	golang.org/x/tools v0.1.0
+	golang.org/x/tools v0.26.0
+)
+
+require (
+	golang.org/x/mod v0.21.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
 )
@@ -0,0 +1,128 @@
+package errproxy
+
+import "time"
+
+// Method describes a wrapped method.  Generated wrappers declare one for each method they wrap.
+type Method struct {
+	Package string // Import path of the wrapped type, such as "database/sql"
+	Type    string // Package-qualified name of the wrapped type, such as "sql.DB"
+	Wrapper string // Name of the generated wrapper type, such as "SqlDB"
+	Name    string // Name of the method, such as "Query"
+	// ReturnsError is true for methods whose last result is an error.  Only these methods can be
+	// failed by a CircuitBreaker, and only these and CarriesError methods by a FaultInjector.
+	ReturnsError bool
+	// Cleanup is true for methods that release resources, such as Close and Rollback.  The
+	// CircuitBreaker never blocks them, so connections aren't leaked while the service is unhealthy.
+	Cleanup bool
+	// CarriesError is true for methods that return a command holding their error rather than an
+	// error, such as go-redis's *StringCmd.  Failed calls return a new command with the error set.
+	CarriesError bool
+	// RecoversPanics is true when the wrapper recovers panics from the method, in which case faults
+	// are injected by Inject inside the recovered call rather than by Allow
+	RecoversPanics bool
+}
+
+func (m *Method) String() string {
+	return m.Type + "." + m.Name
+}
+
+// Call is the event passed to an Interceptor for a single call to a wrapped method
+type Call struct {
+	Method *Method
+	// Args are the arguments passed to the wrapper.  Variadic arguments are passed as a single slice.
+	Args []interface{}
+	// Results are the values returned by the wrapped method, before they are wrapped or transformed.
+	// They are only available in Interceptor.After.
+	Results []interface{}
+	// Err is the error returned by the wrapped method, and Transformed is the error the wrapper returned
+	// after transformation.  Both are nil for methods that don't return an error.
+	Err         error
+	Transformed error
+
+	Start    time.Time
+	Duration time.Duration
+	// Span is the span started for this call by the Proxy's Tracer, if there is one
+	Span Span
+
+	proxy *Proxy
+}
+
+// Interceptor observes every call made through a tree of wrappers
+type Interceptor interface {
+	Before(call *Call)
+	After(call *Call)
+}
+
+type interceptorChain []Interceptor
+
+// Interceptors combines several interceptors into one.  Before is called in order, After is called in
+// reverse order.
+func Interceptors(interceptors ...Interceptor) Interceptor {
+	chain := make(interceptorChain, 0, len(interceptors))
+	for _, interceptor := range interceptors {
+		if interceptor != nil {
+			chain = append(chain, interceptor)
+		}
+	}
+
+	switch len(chain) {
+	case 0:
+		return nil
+	case 1:
+		return chain[0]
+	}
+
+	return chain
+}
+
+func (c interceptorChain) Before(call *Call) {
+	for _, interceptor := range c {
+		interceptor.Before(call)
+	}
+}
+
+func (c interceptorChain) After(call *Call) {
+	for i := len(c) - 1; i >= 0; i-- {
+		c[i].After(call)
+	}
+}
+
+// Passthrough reports whether the transformer returned the wrapped method's error untouched
+func (c *Call) Passthrough() bool {
+	return sameError(c.Err, c.Transformed)
+}
+
+// Before is called by generated wrappers with the call's arguments, right before the wrapped method
+func (c *Call) Before(args ...interface{}) {
+	c.Args = args
+	c.Start = time.Now()
+	c.startSpan()
+	if c.proxy.Interceptor != nil {
+		c.proxy.Interceptor.Before(c)
+	}
+}
+
+// After is called by generated wrappers once the wrapped method has returned and its error has
+// been transformed
+func (c *Call) After(err error, transformed error, results ...interface{}) {
+	c.Duration = time.Since(c.Start)
+	c.Err = err
+	c.Transformed = transformed
+	c.Results = results
+	if c.proxy.Interceptor != nil {
+		c.proxy.Interceptor.After(c)
+	}
+
+	if c.proxy.Journal != nil {
+		c.proxy.Journal.Record(JournalEntry{
+			Time:        c.Start,
+			Method:      c.Method,
+			Args:        c.RedactedArgs(c.proxy.Redact),
+			Duration:    c.Duration,
+			Err:         c.Err,
+			Transformed: c.Transformed,
+		})
+	}
+
+	c.endSpan()
+}
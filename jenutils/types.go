@@ -86,7 +86,17 @@ func Type(stmt *jen.Statement, t types.Type) jen.Code {
 		return Type(stmt.Map(Type(&jen.Statement{}, t.Key())), t.Elem())
 
 	case *types.Interface:
-		return stmt.Interface()
+		var methods []jen.Code
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			methods = append(methods, Type(jen.Null(), t.EmbeddedType(i)))
+		}
+
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			method := t.ExplicitMethod(i)
+			methods = append(methods, signature(jen.Id(method.Name()), method.Type().(*types.Signature)))
+		}
+
+		return stmt.Interface(methods...)
 
 	case *types.Named:
 		if pkg := t.Obj().Pkg(); pkg != nil {
@@ -96,6 +106,14 @@ func Type(stmt *jen.Statement, t types.Type) jen.Code {
 		// builtin interfaces (eg. error) have no package
 		return stmt.Id(t.Obj().Name())
 
+	case *types.Alias:
+		if pkg := t.Obj().Pkg(); pkg != nil {
+			return stmt.Qual(pkg.Path(), t.Obj().Name())
+		}
+
+		// builtin aliases (eg. any) have no package
+		return stmt.Id(t.Obj().Name())
+
 	case *types.Pointer:
 		return Type(stmt.Op("*"), t.Elem())
 
@@ -126,10 +144,20 @@ func Type(stmt *jen.Statement, t types.Type) jen.Code {
 	case *types.Struct:
 		var fields []jen.Code
 
-		for i := 0; i < t.NumFields()-1; i++ {
+		for i := 0; i < t.NumFields(); i++ {
 			field := t.Field(i)
 
-			fields = append(fields, Type(jen.Id(field.Name()), field.Type()))
+			// Tags are part of the type's identity, so they're kept as written
+			name := jen.Id(field.Name())
+			if field.Embedded() {
+				name = jen.Null()
+			}
+
+			fieldCode := jen.Add(Type(name, field.Type()))
+			if t.Tag(i) != "" {
+				fieldCode.Lit(t.Tag(i))
+			}
+			fields = append(fields, fieldCode)
 		}
 
 		return stmt.Struct(fields...)
@@ -138,6 +166,26 @@ func Type(stmt *jen.Statement, t types.Type) jen.Code {
 	panic("unknown type: " + t.String())
 }
 
+// signature renders the parameters and results of a method in an interface
+func signature(stmt *jen.Statement, sig *types.Signature) *jen.Statement {
+	params := make([]jen.Code, sig.Params().Len())
+	for i := 0; i < sig.Params().Len(); i++ {
+		param := sig.Params().At(i)
+		if sig.Variadic() && i == sig.Params().Len()-1 {
+			params[i] = Type(jen.Id(param.Name()).Op("..."), param.Type().(*types.Slice).Elem())
+		} else {
+			params[i] = Type(jen.Id(param.Name()), param.Type())
+		}
+	}
+
+	results := make([]jen.Code, sig.Results().Len())
+	for i := 0; i < sig.Results().Len(); i++ {
+		results[i] = Type(jen.Id(sig.Results().At(i).Name()), sig.Results().At(i).Type())
+	}
+
+	return stmt.Params(params...).Params(results...)
+}
+
 // Import adds an import to the generated file when the type is a named type.
 func Import(file *jen.File, typ types.Type) {
 	switch t := typ.(type) {
@@ -169,6 +217,11 @@ func Import(file *jen.File, typ types.Type) {
 
 		// builtin interfaces (eg. error) have no package
 
+	case *types.Alias:
+		if pkg := t.Obj().Pkg(); pkg != nil {
+			file.ImportName(pkg.Path(), pkg.Name())
+		}
+
 	case *types.Pointer:
 		Import(file, t.Elem())
 
@@ -196,7 +249,7 @@ func IsNillable(typ types.Type) bool {
 	switch t := typ.(type) {
 	case *types.Pointer, *types.Array, *types.Map, *types.Interface, *types.Signature, *types.Chan, *types.Slice:
 		return true
-	case *types.Named:
+	case *types.Named, *types.Alias:
 		return IsNillable(t.Underlying())
 	}
 
@@ -0,0 +1,140 @@
+package errproxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// JournalEntry records a single call that crossed a wrapper
+type JournalEntry struct {
+	Time   time.Time
+	Method *Method
+	Args   []interface{} // Arguments after running them through the Proxy's Redact
+	// Duration is how long the wrapped call took
+	Duration time.Duration
+	// Err is the error returned by the wrapped method, Transformed is the error the wrapper returned
+	Err         error
+	Transformed error
+}
+
+type jsonJournalEntry struct {
+	Time        time.Time `json:"time"`
+	Wrapper     string    `json:"wrapper"`
+	Type        string    `json:"type"`
+	Method      string    `json:"method"`
+	Args        []string  `json:"args,omitempty"`
+	DurationNS  int64     `json:"durationNs"`
+	Err         string    `json:"error,omitempty"`
+	Transformed string    `json:"transformed,omitempty"`
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+// MarshalJSON renders arguments and errors as strings, since they rarely serialize cleanly on
+// their own
+func (e JournalEntry) MarshalJSON() ([]byte, error) {
+	entry := jsonJournalEntry{
+		Time:        e.Time,
+		Wrapper:     e.Method.Wrapper,
+		Type:        e.Method.Type,
+		Method:      e.Method.Name,
+		DurationNS:  int64(e.Duration),
+		Err:         errorText(e.Err),
+		Transformed: errorText(e.Transformed),
+	}
+
+	for _, arg := range e.Args {
+		entry.Args = append(entry.Args, fmt.Sprint(arg))
+	}
+
+	return json.Marshal(entry)
+}
+
+// Journal receives an entry for every call made through a wrapper tree.  Record is called from every
+// goroutine using the tree, so implementations must be safe for concurrent use.
+type Journal interface {
+	Record(entry JournalEntry)
+}
+
+// RingJournal keeps the most recent entries in memory
+type RingJournal struct {
+	lock    sync.Mutex
+	entries []JournalEntry
+	next    int
+	full    bool
+}
+
+func NewRingJournal(size int) *RingJournal {
+	return &RingJournal{
+		entries: make([]JournalEntry, size),
+	}
+}
+
+func (j *RingJournal) Record(entry JournalEntry) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if len(j.entries) == 0 {
+		return
+	}
+
+	j.entries[j.next] = entry
+	j.next = (j.next + 1) % len(j.entries)
+	if j.next == 0 {
+		j.full = true
+	}
+}
+
+// Entries returns the recorded entries, oldest first
+func (j *RingJournal) Entries() []JournalEntry {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if !j.full {
+		return append([]JournalEntry(nil), j.entries[:j.next]...)
+	}
+
+	entries := make([]JournalEntry, 0, len(j.entries))
+	entries = append(entries, j.entries[j.next:]...)
+	return append(entries, j.entries[:j.next]...)
+}
+
+// JSONLinesJournal writes each entry to a writer as a line of JSON
+type JSONLinesJournal struct {
+	lock    sync.Mutex
+	encoder *json.Encoder
+	err     error
+}
+
+func NewJSONLinesJournal(w io.Writer) *JSONLinesJournal {
+	return &JSONLinesJournal{
+		encoder: json.NewEncoder(w),
+	}
+}
+
+func (j *JSONLinesJournal) Record(entry JournalEntry) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	err := j.encoder.Encode(entry)
+	if err != nil && j.err == nil {
+		j.err = err
+	}
+}
+
+// Err returns the first error encountered while writing entries
+func (j *JSONLinesJournal) Err() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	return j.err
+}
@@ -0,0 +1,41 @@
+package errproxy
+
+import (
+	"testing"
+)
+
+func TestRingJournalEntries(t *testing.T) {
+	testCases := []struct {
+		name     string
+		size     int
+		records  int
+		expected []int
+	}{
+		{name: "Empty", size: 3, records: 0, expected: []int{}},
+		{name: "PartlyFull", size: 3, records: 2, expected: []int{0, 1}},
+		{name: "Full", size: 3, records: 3, expected: []int{0, 1, 2}},
+		{name: "Wrapped", size: 3, records: 5, expected: []int{2, 3, 4}},
+		{name: "WrappedTwice", size: 3, records: 7, expected: []int{4, 5, 6}},
+		{name: "ZeroSize", size: 0, records: 2, expected: []int{}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			journal := NewRingJournal(testCase.size)
+			for i := 0; i < testCase.records; i++ {
+				journal.Record(JournalEntry{Args: []interface{}{i}})
+			}
+
+			entries := journal.Entries()
+			if len(entries) != len(testCase.expected) {
+				t.Fatalf("expected %d entries, got %d", len(testCase.expected), len(entries))
+			}
+
+			for i, entry := range entries {
+				if entry.Args[0] != testCase.expected[i] {
+					t.Errorf("entry %d: expected record %d, got %v", i, testCase.expected[i], entry.Args[0])
+				}
+			}
+		})
+	}
+}
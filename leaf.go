@@ -0,0 +1,137 @@
+package errproxy
+
+import (
+	"errors"
+	"strings"
+)
+
+// EachLeaf adapts a transformer to multi-errors, such as those created by errors.Join.  Any error
+// implementing Unwrap() []error is taken apart, each leaf is run through the transformer, and the
+// results are put back together.  Leaves transformed to nil are dropped.  Errors created by
+// errors.Join are rebuilt with errors.Join, while other multi-errors, such as
+// fmt.Errorf("batch: %w; %w", a, b), keep their message with each leaf's text replaced by the
+// transformed leaf's.  Multi-errors wrapped in other errors, such as annotations, are found by
+// following Unwrap() error.  Errors that don't carry multiple errors are passed to the transformer
+// as-is.
+func EachLeaf(t ErrorTransformer) ErrorTransformer {
+	return func(err error) error {
+		return transformLeaves(t, err)
+	}
+}
+
+// rewrappedError keeps the message of a wrapper whose wrapped errors were transformed
+type rewrappedError struct {
+	msg  string
+	errs []error
+}
+
+func (e *rewrappedError) Error() string {
+	return e.msg
+}
+
+func (e *rewrappedError) Unwrap() []error {
+	return e.errs
+}
+
+// rewrappedSingleError is rewrappedError for errors that wrap a single error
+type rewrappedSingleError struct {
+	msg string
+	err error
+}
+
+func (e *rewrappedSingleError) Error() string {
+	return e.msg
+}
+
+func (e *rewrappedSingleError) Unwrap() error {
+	return e.err
+}
+
+func transformLeaves(t ErrorTransformer, err error) error {
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() []error }:
+		children := wrapped.Unwrap()
+		transformed := make([]error, 0, len(children))
+		leaves := make([]error, 0, len(children))
+		for _, child := range children {
+			leaf := transformLeaves(t, child)
+			transformed = append(transformed, leaf)
+			if leaf != nil {
+				leaves = append(leaves, leaf)
+			}
+		}
+
+		if len(leaves) == 0 {
+			return nil
+		}
+
+		if isJoin(err, children) {
+			return errors.Join(leaves...)
+		}
+
+		return &rewrappedError{
+			msg:  replaceMessages(err.Error(), children, transformed),
+			errs: leaves,
+		}
+	case interface{ Unwrap() error }:
+		inner := wrapped.Unwrap()
+		if !containsMultiError(inner) {
+			return Transform(t, err)
+		}
+
+		leaf := transformLeaves(t, inner)
+		if leaf == nil {
+			return nil
+		}
+
+		return &rewrappedSingleError{
+			msg: replaceMessages(err.Error(), []error{inner}, []error{leaf}),
+			err: leaf,
+		}
+	}
+
+	return Transform(t, err)
+}
+
+// isJoin reports whether a multi-error's message is exactly what errors.Join would produce
+func isJoin(err error, children []error) bool {
+	joined := errors.Join(children...)
+	return joined != nil && joined.Error() == err.Error()
+}
+
+func containsMultiError(err error) bool {
+	for err != nil {
+		if _, isMulti := err.(interface{ Unwrap() []error }); isMulti {
+			return true
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return false
+}
+
+// replaceMessages replaces the text of each original error within msg with the text of its transformed
+// counterpart.  Wrapped errors usually follow the wrapper's own text, so they're matched from the end of
+// the message backwards.  Errors transformed to nil have their text removed.
+func replaceMessages(msg string, originals []error, transformed []error) string {
+	suffix := ""
+	rest := msg
+	for i := len(originals) - 1; i >= 0; i-- {
+		text := originals[i].Error()
+		index := strings.LastIndex(rest, text)
+		if index < 0 {
+			continue
+		}
+
+		replacement := ""
+		if transformed[i] != nil {
+			replacement = transformed[i].Error()
+		}
+
+		suffix = replacement + rest[index+len(text):] + suffix
+		rest = rest[:index]
+	}
+
+	return rest + suffix
+}
@@ -0,0 +1,121 @@
+package errproxy
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestEachLeaf(t *testing.T) {
+	errA := errors.New("a")
+	errB := errors.New("b")
+	errDrop := errors.New("drop")
+	errFirst := errors.New("timeout")
+	errSecond := errors.New("timeout")
+
+	replacements := map[error]error{
+		errA:      errors.New("A"),
+		errB:      errors.New("B"),
+		errDrop:   nil,
+		errFirst:  errors.New("first"),
+		errSecond: errors.New("second"),
+	}
+	transformer := EachLeaf(func(err error) error {
+		if replacement, ok := replacements[err]; ok {
+			return replacement
+		}
+		return err
+	})
+
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+		leaves   []error
+	}{
+		{
+			name:     "Plain",
+			err:      errA,
+			expected: "A",
+			leaves:   []error{replacements[errA]},
+		},
+		{
+			name:     "Join",
+			err:      errors.Join(errA, errB),
+			expected: "A\nB",
+			leaves:   []error{replacements[errA], replacements[errB]},
+		},
+		{
+			name:     "ErrorfMultiple",
+			err:      fmt.Errorf("batch: %w; %w", errA, errB),
+			expected: "batch: A; B",
+			leaves:   []error{replacements[errA], replacements[errB]},
+		},
+		{
+			name:     "WrappedJoin",
+			err:      fmt.Errorf("save: %w", errors.Join(errA, errB)),
+			expected: "save: A\nB",
+			leaves:   []error{replacements[errA], replacements[errB]},
+		},
+		{
+			name:     "LeafToNil",
+			err:      fmt.Errorf("batch: %w; %w", errA, errDrop),
+			expected: "batch: A; ",
+			leaves:   []error{replacements[errA]},
+		},
+		{
+			name:     "JoinLeafToNil",
+			err:      errors.Join(errDrop, errB),
+			expected: "B",
+			leaves:   []error{replacements[errB]},
+		},
+		{
+			name: "AllLeavesToNil",
+			err:  fmt.Errorf("save: %w", errors.Join(errDrop, errDrop)),
+		},
+		{
+			name:     "RepeatedText",
+			err:      fmt.Errorf("%w then %w", errFirst, errSecond),
+			expected: "first then second",
+			leaves:   []error{replacements[errFirst], replacements[errSecond]},
+		},
+		{
+			name:     "RepeatedTextInWrapper",
+			err:      fmt.Errorf("timeout: %w then %w", errFirst, errSecond),
+			expected: "timeout: first then second",
+			leaves:   []error{replacements[errFirst], replacements[errSecond]},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			transformed := transformer(testCase.err)
+			if testCase.leaves == nil {
+				if transformed != nil {
+					t.Fatalf("expected nil, got %v", transformed)
+				}
+				return
+			}
+
+			if transformed == nil {
+				t.Fatalf("expected %q, got nil", testCase.expected)
+			}
+
+			if transformed.Error() != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, transformed.Error())
+			}
+
+			for _, leaf := range testCase.leaves {
+				if !errors.Is(transformed, leaf) {
+					t.Errorf("expected %q to wrap %q", transformed, leaf)
+				}
+			}
+
+			for original, replacement := range replacements {
+				if replacement != nil && original != replacement && errors.Is(transformed, original) {
+					t.Errorf("expected %q to no longer wrap the original %q", transformed, original)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package errproxy
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OpenResource is a wrapped object with a Close method that hasn't been closed yet
+type OpenResource struct {
+	Wrapper string
+	Created time.Time
+	pcs     []uintptr
+}
+
+// Stack renders the stack of the call that created the wrapper
+func (r OpenResource) Stack() string {
+	out := new(strings.Builder)
+	frames := runtime.CallersFrames(r.pcs)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(out, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+
+	return out.String()
+}
+
+// LeakTracker keeps track of wrapped objects that need to be closed, such as *sql.Rows, for wrappers
+// generated with trackLeaks.  Set it on the root Proxy and every wrapper with a Close() error method
+// will register itself when it's created and unregister when it's closed.
+type LeakTracker struct {
+	lock sync.Mutex
+	open map[interface{}]OpenResource
+}
+
+func NewLeakTracker() *LeakTracker {
+	return &LeakTracker{
+		open: make(map[interface{}]OpenResource),
+	}
+}
+
+// Open returns the objects that are still open, oldest first
+func (l *LeakTracker) Open() []OpenResource {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	open := make([]OpenResource, 0, len(l.open))
+	for _, resource := range l.open {
+		open = append(open, resource)
+	}
+
+	sort.Slice(open, func(i, j int) bool {
+		return open[i].Created.Before(open[j].Created)
+	})
+
+	return open
+}
+
+// Report describes every object that's still open, along with where it was created
+func (l *LeakTracker) Report() string {
+	out := new(strings.Builder)
+	for _, resource := range l.Open() {
+		fmt.Fprintf(out, "%s created at %s and never closed:\n%s\n", resource.Wrapper, resource.Created.Format(time.RFC3339Nano), resource.Stack())
+	}
+
+	return out.String()
+}
+
+// TrackOpen is called by generated Wrap functions for types with a Close method
+func (p *Proxy) TrackOpen(wrapper string, inner interface{}) {
+	if p == nil || p.Leaks == nil || !reflect.TypeOf(inner).Comparable() {
+		return
+	}
+
+	// Skip runtime.Callers, TrackOpen and the Wrap function
+	pcs := make([]uintptr, 32)
+	captured := runtime.Callers(3, pcs)
+
+	p.Leaks.lock.Lock()
+	defer p.Leaks.lock.Unlock()
+
+	p.Leaks.open[inner] = OpenResource{
+		Wrapper: wrapper,
+		Created: time.Now(),
+		pcs:     pcs[:captured],
+	}
+}
+
+// TrackClose is called by generated Close methods once the wrapped object has been closed
+func (p *Proxy) TrackClose(inner interface{}) {
+	if p == nil || p.Leaks == nil || !reflect.TypeOf(inner).Comparable() {
+		return
+	}
+
+	p.Leaks.lock.Lock()
+	defer p.Leaks.lock.Unlock()
+
+	delete(p.Leaks.open, inner)
+}
@@ -0,0 +1,19 @@
+// Package leaktest fails tests that leave wrapped objects open
+package leaktest
+
+import (
+	"testing"
+
+	"github.com/CannibalVox/errproxy"
+)
+
+// Check fails the test if the tracker has any objects that haven't been closed.  Call it at the end
+// of a test, or register it with t.Cleanup.
+func Check(t testing.TB, tracker *errproxy.LeakTracker) {
+	t.Helper()
+
+	open := tracker.Open()
+	if len(open) > 0 {
+		t.Errorf("%d wrapped objects were never closed:\n%s", len(open), tracker.Report())
+	}
+}
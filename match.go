@@ -0,0 +1,35 @@
+package errproxy
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchMethod reports whether a method matches a pattern.  Patterns containing a dot are
+// matched as Type.Method, where both halves are path.Match globs and Type is the unqualified name of
+// the wrapped type, such as "Client.Get" or "Tx.*".  Patterns with no dot only match the method name,
+// so "Exec*" matches Exec methods on every type.  The same patterns are used by the generation
+// config and by runtime options.
+func MatchMethod(pattern string, typeName string, methodName string) bool {
+	typePattern, methodPattern, hasType := strings.Cut(pattern, ".")
+	if !hasType {
+		methodPattern = typePattern
+	} else if matched, _ := path.Match(typePattern, typeName); !matched {
+		return false
+	}
+
+	matched, _ := path.Match(methodPattern, methodName)
+	return matched
+}
+
+// Matches reports whether the method matches any of the provided patterns.  See MatchMethod.
+func (m *Method) Matches(patterns ...string) bool {
+	typeName := m.Type[strings.LastIndex(m.Type, ".")+1:]
+	for _, pattern := range patterns {
+		if MatchMethod(pattern, typeName, m.Name) {
+			return true
+		}
+	}
+
+	return false
+}
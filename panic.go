@@ -0,0 +1,36 @@
+package errproxy
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is returned by wrappers generated with recoverPanics when the wrapped method panics
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("recovered from panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it was an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// RecoverPanic stores a PanicError in errOut if the calling function is panicking.  It must be
+// deferred directly: defer errproxy.RecoverPanic(&err)
+func RecoverPanic(errOut *error) {
+	value := recover()
+	if value == nil {
+		return
+	}
+
+	*errOut = &PanicError{
+		Value: value,
+		Stack: debug.Stack(),
+	}
+}
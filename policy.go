@@ -0,0 +1,49 @@
+package errproxy
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrMethodDenied = errors.New("method denied by policy")
+
+// MethodPolicy decides which methods a wrapper tree is allowed to call, using the same patterns as
+// MatchMethod.  Set it on the root Proxy to enforce it on every wrapper in the tree, such as a
+// read-only policy for reporting jobs:
+//
+//	proxy.Policy = &errproxy.MethodPolicy{Deny: []string{"Exec*", "Set*", "Del*", "Begin*"}}
+type MethodPolicy struct {
+	// Allow lists the only methods that may be called.  When it's empty, every method not denied is allowed.
+	Allow []string
+	// Deny lists methods that may not be called.  Deny wins over Allow.
+	Deny []string
+}
+
+// Permits reports whether the policy allows a method to be called.  A nil policy allows everything.
+func (p *MethodPolicy) Permits(method *Method) bool {
+	if p == nil {
+		return true
+	}
+
+	if method.Matches(p.Deny...) {
+		return false
+	}
+
+	return len(p.Allow) == 0 || method.Matches(p.Allow...)
+}
+
+// checkPolicy returns an error wrapping ErrMethodDenied if the proxy's policy denies the method.
+// Methods that return a command, like go-redis's *StringCmd, get the error set on a new command.
+// Methods that can't report an error at all panic with it instead, since the call can't be skipped quietly.
+func (p *Proxy) checkPolicy(method *Method) error {
+	if p.Policy.Permits(method) {
+		return nil
+	}
+
+	err := fmt.Errorf("%s: %w", method, ErrMethodDenied)
+	if !method.ReturnsError && !method.CarriesError {
+		panic(err)
+	}
+
+	return err
+}
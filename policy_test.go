@@ -0,0 +1,36 @@
+package errproxy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProxyAllowPolicy(t *testing.T) {
+	testCases := []struct {
+		name        string
+		method      *Method
+		expectErr   bool
+		expectPanic bool
+	}{
+		{name: "Allowed", method: &Method{Type: "redis.Client", Name: "Get", CarriesError: true}},
+		{name: "ReturnsError", method: &Method{Type: "sql.DB", Name: "Exec", ReturnsError: true}, expectErr: true},
+		{name: "CarriesError", method: &Method{Type: "redis.Client", Name: "Del", CarriesError: true}, expectErr: true},
+		{name: "NoError", method: &Method{Type: "redis.Client", Name: "SetLimiter"}, expectPanic: true},
+	}
+
+	proxy := &Proxy{Policy: &MethodPolicy{Deny: []string{"Exec*", "Set*", "Del*"}}}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != testCase.expectPanic {
+					t.Errorf("expected panic %t, got %v", testCase.expectPanic, r)
+				}
+			}()
+
+			err := proxy.Allow(testCase.method)
+			if testCase.expectErr != errors.Is(err, ErrMethodDenied) {
+				t.Errorf("expected denied %t, got %v", testCase.expectErr, err)
+			}
+		})
+	}
+}
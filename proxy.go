@@ -0,0 +1,79 @@
+package errproxy
+
+// Proxy carries the runtime configuration for a tree of generated wrappers.  It's passed to the
+// root Wrap function and handed down to every wrapper created from a method's return value.  Set
+// its fields before wrapping anything, wrappers don't expect them to change underneath them- use
+// TransformerRef.Store to change the transformer on the fly.
+type Proxy struct {
+	Transformer *TransformerRef
+	// Interceptor is notified before and after every wrapped call.  When it, Journal and Tracer are
+	// nil, wrappers skip building call events entirely.
+	Interceptor Interceptor
+	// Retry re-invokes idempotent methods when they fail.  Methods are marked idempotent in the
+	// generation config.
+	Retry *RetryPolicy
+	// Breaker short-circuits calls with ErrCircuitOpen while the wrapped service is failing
+	Breaker *CircuitBreaker
+	// Faults injects errors, latency and panics into calls for testing
+	Faults *FaultInjector
+	// Journal receives a record of every call made through the tree
+	Journal Journal
+	// Redact is applied to arguments before they're recorded in the Journal
+	Redact Redactor
+	// Leaks tracks wrapped objects that haven't been closed
+	Leaks *LeakTracker
+	// Policy decides which methods may be called
+	Policy *MethodPolicy
+	// Tracer starts a span for every call
+	Tracer Tracer
+
+	// span is the span of the call that returned this wrapper
+	span Span
+}
+
+// New creates a Proxy for a new wrapper tree that uses the provided transformer
+func New(t ErrorTransformer) *Proxy {
+	return &Proxy{
+		Transformer: NewTransformerRef(t),
+	}
+}
+
+// Transform runs err through the tree's transformer.  It's safe to call on a nil Proxy, which uses
+// the default transformer.
+func (p *Proxy) Transform(err error) error {
+	if p == nil {
+		return defaultTransformer.Transform(err)
+	}
+
+	return p.Transformer.Transform(err)
+}
+
+// Derive returns the Proxy a child wrapper should use.  The child shares everything with its parent,
+// aside from the transformer, which is derived with TransformerRef.Derive.
+func (p *Proxy) Derive(childType string, method string) *Proxy {
+	if p == nil {
+		return nil
+	}
+
+	transformer := p.Transformer.Derive(childType, method)
+	if transformer == p.Transformer {
+		return p
+	}
+
+	child := *p
+	child.Transformer = transformer
+	return &child
+}
+
+// Intercept starts a call event for a wrapped method, or returns nil when there's nothing
+// interested in one
+func (p *Proxy) Intercept(method *Method) *Call {
+	if p == nil || (p.Interceptor == nil && p.Journal == nil && p.Tracer == nil) {
+		return nil
+	}
+
+	return &Call{
+		Method: method,
+		proxy:  p,
+	}
+}
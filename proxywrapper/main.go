@@ -1,17 +1,19 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	gotypes "go/types"
-	"io/fs"
-	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime/debug"
+	"strconv"
 	"strings"
 
 	"golang.org/x/tools/go/packages"
 
+	"github.com/CannibalVox/errproxy/config"
 	"github.com/CannibalVox/errproxy/filegen"
 	"github.com/CannibalVox/errproxy/types"
 )
@@ -21,19 +23,21 @@ var additionalInputPackages string
 var typeName string
 var outputPath string
 var outputPackage string
+var configPath string
 
 func init() {
 	flag.StringVar(&inputPackageName, "input", "", "package URL to read the type from")
 	flag.StringVar(&additionalInputPackages, "additionalPkgs", "", "comma separated list of package URLs- types in these packages should be wrapped if located in the dendency graph of the original type")
 	flag.StringVar(&typeName, "type", "", "type to read & wrap")
-	flag.StringVar(&outputPath, "output", "", "package URL to write generated types to")
-	flag.StringVar(&outputPackage, "pkg", "", "package name to use for generated code- defaults to folder name")
+	flag.StringVar(&outputPath, "output", "", "directory (starting with ./, ../ or /), or import path inside the current module, to write generated types to")
+	flag.StringVar(&outputPackage, "pkg", "", "package name to use for generated code- defaults to the package already in the output directory, or the last element of its import path")
+	flag.StringVar(&configPath, "config", "", "path to a JSON generation config, see the config package for options")
 }
 
-func loadType(inputPackage string, inputType string, allPackages []string) (gotypes.Type, []string) {
-	// Load requested package
+func loadType(inputPackage string, inputType string, allPackages []string) (gotypes.Type, []string, []*packages.Package) {
+	// Load requested package, along with its syntax so that doc comments can be copied to the wrappers
 	cfg := &packages.Config{
-		Mode: packages.NeedName | packages.NeedDeps | packages.NeedImports | packages.NeedTypes | packages.NeedTypesInfo,
+		Mode: packages.NeedName | packages.NeedDeps | packages.NeedImports | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax | packages.NeedFiles | packages.NeedModule,
 	}
 
 	// We need to get the fully qualified package path for the input package (to make sure we're getting the type)
@@ -71,42 +75,91 @@ func loadType(inputPackage string, inputType string, allPackages []string) (goty
 		log.Fatalf("Type '%s' could not be located in package %s\n", typeName, inputPackage)
 	}
 
-	return locatedTypeDef.Type(), fullQualifiedPackages
+	return locatedTypeDef.Type(), fullQualifiedPackages, pkgs
 }
 
-func deleteGeneratedFiles(generationPath string) error {
-	err := filepath.WalkDir(generationPath, func(path string, d fs.DirEntry, err error) error {
+// deleteGeneratedFiles removes stale generated files from the output directory.  Only files listed in the
+// previous manifest are considered, or every go file in the directory if there's no manifest from an
+// earlier version, and files are only removed if they still carry the generated header.
+func deleteGeneratedFiles(generationPath string, owned []string, keep map[string]bool) error {
+	if owned == nil {
+		entries, err := os.ReadDir(generationPath)
 		if err != nil {
 			return err
 		}
 
-		if path == generationPath {
-			return nil
+		for _, entry := range entries {
+			if !entry.IsDir() && filepath.Ext(entry.Name()) == ".go" {
+				owned = append(owned, entry.Name())
+			}
+		}
+	}
+
+	for _, fileName := range owned {
+		if keep[fileName] || filepath.Base(fileName) != fileName {
+			continue
 		}
 
-		if d.IsDir() {
-			return nil
+		path := filepath.Join(generationPath, fileName)
+		text, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
 		}
 
-		if filepath.Ext(path) != ".go" {
-			return nil
+		if !filegen.IsGenerated(text) {
+			continue
 		}
 
-		text, err := ioutil.ReadFile(path)
+		err = os.Remove(path)
 		if err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
 
-		if !strings.HasPrefix(string(text), "// ErrProxy Generated File, DO NOT EDIT") {
-			return nil
+// generationHeader describes this run for the header of each generated file
+func generationHeader(inputPackages []*packages.Package, typeToWrap gotypes.Type) filegen.Header {
+	header := filegen.Header{
+		GeneratorVersion: "(devel)",
+		Command:          commandLine(),
+	}
+
+	buildInfo, ok := debug.ReadBuildInfo()
+	if ok && buildInfo.Main.Version != "" {
+		header.GeneratorVersion = buildInfo.Main.Version
+	}
+
+	named, ok := typeToWrap.(interface{ Obj() *gotypes.TypeName })
+	if !ok || named.Obj().Pkg() == nil {
+		return header
+	}
+
+	header.InputPackage = named.Obj().Pkg().Path()
+	for _, pkg := range inputPackages {
+		if pkg.PkgPath == header.InputPackage && pkg.Module != nil {
+			header.InputModule = pkg.Module.Path
+			header.InputVersion = pkg.Module.Version
 		}
+	}
 
-		os.Remove(path)
+	return header
+}
 
-		return nil
-	})
+// commandLine is the command proxywrapper was run with, without the path to the binary
+func commandLine() string {
+	args := []string{"proxywrapper"}
+	for _, arg := range os.Args[1:] {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			arg = strconv.Quote(arg)
+		}
+		args = append(args, arg)
+	}
 
-	return err
+	return strings.Join(args, " ")
 }
 
 func main() {
@@ -117,7 +170,17 @@ func main() {
 		return
 	}
 
-	outputPath, err := filepath.Abs(outputPath)
+	outputPath, outputImportPath, err := resolveOutput(outputPath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	outputPackage, err = outputPackageName(outputPackage, outputPath, outputImportPath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	genConfig, err := config.Load(configPath)
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -133,7 +196,14 @@ func main() {
 	// The user may not have entered a fully-qualified pkg, so load the pkgs they asked for and build a new pkg list
 	// from the fully-qualified names
 
-	typeToWrap, fullyQualifiedPackages := loadType(inputPackageName, typeName, allPackages)
+	typeToWrap, fullyQualifiedPackages, inputPackages := loadType(inputPackageName, typeName, allPackages)
+	err = checkOutputPackage(inputPackages, outputPath, outputImportPath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	docs := filegen.IndexDocs(inputPackages)
+	header := generationHeader(inputPackages, typeToWrap)
 	walker := types.NewTypeWalker(fullyQualifiedPackages)
 
 	switch typeToWrap.(type) {
@@ -143,41 +213,28 @@ func main() {
 	walker.QueueType(typeToWrap, nil)
 	typeDB := walker.WalkTypes()
 
-	_, err = os.Stat(outputPath)
-	if err != nil && !os.IsNotExist(err) {
+	lockedNames, err := readNamesLock(outputPath)
+	if err != nil {
 		log.Fatalln(err)
 	}
+	lockedNames = typeDB.ResolveAnonymousNames(genConfig.AnonymousNames, lockedNames)
 
-	if !os.IsNotExist(err) {
-		if err != nil {
-			log.Fatalln(err)
-		}
-
-		// The folder already exists, so we should check if all files within are ErrProxy-generated go files
-		// If not, error out.  If so, delete the folder
-		err := deleteGeneratedFiles(outputPath)
-		if err != nil {
-			log.Fatalln(err)
-		}
-	} else {
-		err = os.MkdirAll(outputPath, 0755)
-		if err != nil {
-			log.Fatalln(err)
-		}
+	renames, err := typeDB.ResolveNameCollisions()
+	if err != nil {
+		log.Fatalln(err)
 	}
 
-	// If no -pkg flag was passed in, just break off the last folder in the path as the package name
-	if outputPackage == "" {
-		_, outputPackage = filepath.Split(outputPath)
+	for _, rename := range renames {
+		log.Printf("Name collision: %s", rename)
 	}
 
 	// Create files, base types, wrapper function for base types
 	fileGens := make(map[string]*filegen.FileCreate)
 	err = typeDB.WalkRootTypes(func(t *types.RootTypeInfo) error {
-		fileGen := filegen.NewFile(outputPackage, typeDB.LocateTypeInfo(t.RootType.Type), typeDB)
+		fileGen, err := filegen.NewFile(outputPackage, typeDB.LocateTypeInfo(t.RootType.Type), typeDB, genConfig, docs, header)
 		fileGens[t.RootType.TypeKey] = fileGen
 
-		return nil
+		return err
 	})
 
 	if err != nil {
@@ -196,12 +253,29 @@ func main() {
 		log.Fatalln(err)
 	}
 
-	//Output generated files
+	// Render everything before touching the output directory, so a failure leaves it as it was
+	files := make(map[string][]byte, len(fileGens))
 	for _, fileGen := range fileGens {
-		err := fileGen.WriteFile(outputPath)
+		text, err := fileGen.Render()
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		files[fileGen.FileName()] = text
+	}
+
+	if len(lockedNames) > 0 {
+		lockText, err := json.MarshalIndent(lockedNames, "", "\t")
 		if err != nil {
 			log.Fatalln(err)
 		}
+
+		files[namesLockName] = append(lockText, '\n')
+	}
+
+	err = writeOutput(outputPath, files)
+	if err != nil {
+		log.Fatalln(err)
 	}
 
 	log.Printf("Successfully generated wrapper in %s", outputPath)
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/CannibalVox/errproxy/filegen"
+)
+
+type module struct {
+	Path string
+	Dir  string
+}
+
+// listModules returns the main modules of the current directory, which is more than one when a
+// go.work file is in use.  It returns nothing outside of a module.
+func listModules() []module {
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Path}}\t{{.Dir}}").Output()
+	if err != nil {
+		return nil
+	}
+
+	var modules []module
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		modPath, modDir, found := strings.Cut(line, "\t")
+		if found && modDir != "" {
+			modules = append(modules, module{Path: modPath, Dir: modDir})
+		}
+	}
+
+	return modules
+}
+
+// resolveOutput turns the -output flag into a directory and the import path of the package in it.
+// The flag may be an import path inside one of the current modules, or a relative or absolute directory.
+// Anything else is an error, rather than a guess at a directory.  The import path is empty when the
+// directory isn't inside a module.
+func resolveOutput(output string) (dir string, importPath string, err error) {
+	modules := listModules()
+
+	isRelative := output == "." || output == ".." || strings.HasPrefix(output, "./") || strings.HasPrefix(output, "../")
+	if !isRelative && !filepath.IsAbs(output) {
+		// Use the longest matching module, so nested modules win over their parents
+		var owner *module
+		for i, mod := range modules {
+			if (output == mod.Path || strings.HasPrefix(output, mod.Path+"/")) && (owner == nil || len(mod.Path) > len(owner.Path)) {
+				owner = &modules[i]
+			}
+		}
+
+		if owner == nil {
+			return "", "", fmt.Errorf("-output %s is not an import path inside the current module- use ./%s for a directory", output, output)
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(output, owner.Path), "/")
+		return filepath.Join(owner.Dir, filepath.FromSlash(rel)), output, nil
+	}
+
+	dir, err = filepath.Abs(output)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, mod := range modules {
+		rel, err := filepath.Rel(mod.Dir, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		candidate := path.Join(mod.Path, filepath.ToSlash(rel))
+		if len(candidate) > len(importPath) {
+			importPath = candidate
+		}
+	}
+
+	return dir, importPath, nil
+}
+
+var invalidPackageChars = regexp.MustCompile(`[^a-z0-9_]`)
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// packageNameFromPath derives a package name from an import path, skipping major version suffixes
+// like /v2 and characters that aren't allowed in package names
+func packageNameFromPath(importPath string) string {
+	elements := strings.Split(importPath, "/")
+	name := elements[len(elements)-1]
+	if majorVersion.MatchString(name) && len(elements) > 1 {
+		name = elements[len(elements)-2]
+	}
+
+	name = strings.TrimPrefix(strings.ToLower(name), "go-")
+	return invalidPackageChars.ReplaceAllString(name, "")
+}
+
+// existingPackageName returns the package name used by the non-generated go files already in a
+// directory, or an empty string if there are none
+func existingPackageName(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	fileSet := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		text, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return "", err
+		}
+
+		if filegen.IsGenerated(text) {
+			continue
+		}
+
+		file, err := parser.ParseFile(fileSet, name, text, parser.PackageClauseOnly)
+		if err != nil {
+			return "", err
+		}
+
+		return file.Name.Name, nil
+	}
+
+	return "", nil
+}
+
+// outputPackageName picks the package name for generated code.  An explicitly requested name must match
+// the package of any hand-written files already in the directory.  Otherwise, the existing package name
+// is used, then one derived from the import path, then the folder name.
+func outputPackageName(requested string, dir string, importPath string) (string, error) {
+	existing, err := existingPackageName(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if requested != "" {
+		if existing != "" && existing != requested {
+			return "", fmt.Errorf("-pkg %s does not match package %s already in %s", requested, existing, dir)
+		}
+
+		return requested, nil
+	}
+
+	if existing != "" {
+		return existing, nil
+	}
+
+	if importPath != "" {
+		return packageNameFromPath(importPath), nil
+	}
+
+	return packageNameFromPath(filepath.Base(dir)), nil
+}
+
+// checkOutputPackage refuses to generate into one of the input packages, or into a package that the
+// input packages import, since the generated code imports them and would create a cycle
+func checkOutputPackage(inputPackages []*packages.Package, dir string, importPath string) error {
+	isInput := make(map[*packages.Package]bool, len(inputPackages))
+	for _, pkg := range inputPackages {
+		isInput[pkg] = true
+	}
+
+	var err error
+	packages.Visit(inputPackages, func(pkg *packages.Package) bool {
+		if err != nil {
+			return false
+		}
+
+		matches := importPath != "" && pkg.PkgPath == importPath
+		if len(pkg.GoFiles) > 0 && filepath.Dir(pkg.GoFiles[0]) == dir {
+			matches = true
+		}
+
+		if matches && isInput[pkg] {
+			err = fmt.Errorf("output directory %s is the input package %s", dir, pkg.PkgPath)
+		} else if matches {
+			err = fmt.Errorf("output package %s is imported by the input packages, so generating into it would create an import cycle", pkg.PkgPath)
+		}
+
+		return err == nil
+	}, nil)
+
+	return err
+}
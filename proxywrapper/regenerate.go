@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/CannibalVox/errproxy/filegen"
+	"github.com/CannibalVox/errproxy/types"
+)
+
+// manifestName is the file in the output directory listing the files proxywrapper owns there, so that
+// files from a previous run that are no longer generated can be removed without touching anything else
+const manifestName = ".proxywrapper-manifest.json"
+
+// namesLockName is the file in the output directory that keeps the names of anonymous wrappers stable
+// between runs
+const namesLockName = ".proxywrapper-names.json"
+
+type manifest struct {
+	Files []string `json:"files"`
+}
+
+// readManifest returns the files listed in a directory's manifest, or nil if it doesn't have one
+func readManifest(dir string) ([]string, error) {
+	text, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var owned manifest
+	err = json.Unmarshal(text, &owned)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", manifestName, err)
+	}
+
+	return owned.Files, nil
+}
+
+// readNamesLock returns the names locked by a previous run, or nil if there was none
+func readNamesLock(dir string) ([]types.LockedName, error) {
+	text, err := os.ReadFile(filepath.Join(dir, namesLockName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var locked []types.LockedName
+	err = json.Unmarshal(text, &locked)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", namesLockName, err)
+	}
+
+	return locked, nil
+}
+
+// writeOutput replaces the generated files in dir with files.  Everything is written to a temporary
+// directory first and then renamed into place, so a failure part way through leaves the previous
+// generation intact rather than a half-deleted package.  Files from the previous generation that
+// aren't in this one are removed last.
+func writeOutput(dir string, files map[string][]byte) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	previous, err := readManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	// Refuse to replace anything that isn't ours before changing anything
+	fileNames := make([]string, 0, len(files))
+	for fileName := range files {
+		existing, err := os.ReadFile(filepath.Join(dir, fileName))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if err == nil && filepath.Ext(fileName) == ".go" && !filegen.IsGenerated(existing) {
+			return fmt.Errorf("%s was not generated by proxywrapper and would be overwritten", filepath.Join(dir, fileName))
+		}
+
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	// Directories starting with . are ignored by the go tool, so the temporary directory can't break
+	// the build of the output package while it exists
+	tempDir, err := os.MkdirTemp(dir, ".proxywrapper-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, fileName := range fileNames {
+		err = os.WriteFile(filepath.Join(tempDir, fileName), files[fileName], 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	manifestText, err := json.MarshalIndent(manifest{Files: fileNames}, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filepath.Join(tempDir, manifestName), append(manifestText, '\n'), 0644)
+	if err != nil {
+		return err
+	}
+
+	// Swap the new files in, then the manifest that owns them
+	for _, fileName := range append(fileNames, manifestName) {
+		err = os.Rename(filepath.Join(tempDir, fileName), filepath.Join(dir, fileName))
+		if err != nil {
+			return err
+		}
+	}
+
+	keep := make(map[string]bool, len(files))
+	for fileName := range files {
+		keep[fileName] = true
+	}
+
+	return deleteGeneratedFiles(dir, previous, keep)
+}
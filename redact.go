@@ -0,0 +1,34 @@
+package errproxy
+
+// Redacted replaces arguments hidden by RedactArgs
+const Redacted = "[REDACTED]"
+
+// Redactor returns the value that should be recorded for an argument to a wrapped method, so that
+// sensitive values stay out of logs and journals
+type Redactor func(method *Method, index int, arg interface{}) interface{}
+
+// RedactArgs returns a Redactor that hides every argument to methods matching any of the patterns.
+// See MatchMethod for the pattern format.
+func RedactArgs(patterns ...string) Redactor {
+	return func(method *Method, index int, arg interface{}) interface{} {
+		if method.Matches(patterns...) {
+			return Redacted
+		}
+
+		return arg
+	}
+}
+
+// RedactedArgs returns a copy of the call's arguments with the redactor applied.  A nil redactor
+// returns the arguments as they are.
+func (c *Call) RedactedArgs(redact Redactor) []interface{} {
+	args := make([]interface{}, len(c.Args))
+	for i, arg := range c.Args {
+		if redact != nil {
+			arg = redact(c.Method, i, arg)
+		}
+		args[i] = arg
+	}
+
+	return args
+}
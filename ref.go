@@ -0,0 +1,89 @@
+package errproxy
+
+import "sync/atomic"
+
+// TransformerRef holds the transformer for a tree of generated wrappers.  Every wrapper produced
+// from a root wrapper shares the root's TransformerRef by pointer, so calling Store swaps the
+// transformer for the entire tree at once.
+type TransformerRef struct {
+	transformer atomic.Pointer[ErrorTransformer]
+	factory     atomic.Pointer[TransformerFactory]
+}
+
+// TransformerFactory derives the transformer for a child wrapper from its parent's transformer.  It's
+// called whenever a generated method wraps a returned object, with the wrapper type name of the child
+// (such as "SqlTx") and the name of the method that produced it (such as "Begin").  The parent
+// transformer always reflects the parent ref's current transformer, so swaps made with Store flow
+// through to derived children.
+type TransformerFactory func(parent ErrorTransformer, childType string, method string) ErrorTransformer
+
+var defaultTransformer = &TransformerRef{}
+
+func NewTransformerRef(t ErrorTransformer) *TransformerRef {
+	ref := &TransformerRef{}
+	ref.Store(t)
+	return ref
+}
+
+// SetDefaultTransformer sets the process-wide transformer used by wrappers that were given
+// a nil TransformerRef, or a TransformerRef holding a nil transformer.  By default, errors are
+// passed through untouched.
+func SetDefaultTransformer(t ErrorTransformer) {
+	defaultTransformer.Store(t)
+}
+
+func DefaultTransformer() ErrorTransformer {
+	return defaultTransformer.Load()
+}
+
+// Store atomically replaces the transformer for every wrapper sharing this ref
+func (r *TransformerRef) Store(t ErrorTransformer) {
+	r.transformer.Store(&t)
+}
+
+// SetFactory sets the factory used to derive transformers for child wrappers.  The factory is inherited
+// by every derived ref, so it runs again at each level of the wrapper tree.
+func (r *TransformerRef) SetFactory(f TransformerFactory) {
+	r.factory.Store(&f)
+}
+
+// Derive returns the ref a child wrapper should use.  Without a factory, the child shares this ref.
+func (r *TransformerRef) Derive(childType string, method string) *TransformerRef {
+	if r == nil {
+		return nil
+	}
+
+	factory := r.factory.Load()
+	if factory == nil || *factory == nil {
+		return r
+	}
+
+	child := NewTransformerRef((*factory)(r.Transform, childType, method))
+	child.factory.Store(factory)
+	return child
+}
+
+// Load returns the current transformer, falling back to the default transformer if none is set
+func (r *TransformerRef) Load() ErrorTransformer {
+	if r != nil {
+		t := r.transformer.Load()
+		if t != nil && *t != nil {
+			return *t
+		}
+	}
+
+	if r == defaultTransformer {
+		return nil
+	}
+
+	return defaultTransformer.Load()
+}
+
+// Transform runs err through the current transformer.  It's safe to call on a nil ref.
+func (r *TransformerRef) Transform(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return Transform(r.Load(), err)
+}
@@ -0,0 +1,79 @@
+package errproxy
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryPolicy re-invokes methods that were marked idempotent in the generation config when they fail.
+// The transformer only sees the error from the final attempt.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of calls to make, including the first
+	MaxAttempts int
+	// Backoff returns the delay before the next attempt, given the number of attempts made so far.
+	// A nil Backoff retries immediately.
+	Backoff func(attempt int) time.Duration
+	// Retryable reports whether an error is worth retrying.  A nil Retryable retries every error aside
+	// from context cancellation and deadlines.  Recovered panics are never retried, whatever Retryable
+	// says.
+	Retryable func(err error) bool
+}
+
+// ExponentialBackoff doubles the delay after each attempt, starting from base and capped at max
+func ExponentialBackoff(base time.Duration, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 1; i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+
+		if delay > max {
+			return max
+		}
+
+		return delay
+	}
+}
+
+func (r *RetryPolicy) retryable(err error) bool {
+	if r.Retryable != nil {
+		return r.Retryable(err)
+	}
+
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// ShouldRetry is called by generated wrappers after each attempt at an idempotent method.  It waits out
+// the backoff and returns true if the method should be called again, or returns false right away if
+// the call succeeded or panicked, the policy is spent, or ctx is done.
+func (p *Proxy) ShouldRetry(ctx context.Context, attempt int, err error) bool {
+	if p == nil || p.Retry == nil || err == nil {
+		return false
+	}
+
+	// A method that panicked will most likely panic again
+	var panicErr *PanicError
+	if attempt >= p.Retry.MaxAttempts || errors.As(err, &panicErr) || !p.Retry.retryable(err) {
+		return false
+	}
+
+	var delay time.Duration
+	if p.Retry.Backoff != nil {
+		delay = p.Retry.Backoff(attempt)
+	}
+
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
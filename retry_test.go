@@ -0,0 +1,95 @@
+package errproxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+
+	testCases := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{attempt: 1, expected: 10 * time.Millisecond},
+		{attempt: 2, expected: 20 * time.Millisecond},
+		{attempt: 3, expected: 40 * time.Millisecond},
+		{attempt: 4, expected: 50 * time.Millisecond},
+		{attempt: 20, expected: 50 * time.Millisecond},
+	}
+
+	for _, testCase := range testCases {
+		if delay := backoff(testCase.attempt); delay != testCase.expected {
+			t.Errorf("attempt %d: expected %s, got %s", testCase.attempt, testCase.expected, delay)
+		}
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		name     string
+		policy   *RetryPolicy
+		ctx      context.Context
+		attempt  int
+		err      error
+		expected bool
+	}{
+		{name: "NoPolicy", policy: nil, attempt: 1, err: errBoom, expected: false},
+		{name: "Success", policy: &RetryPolicy{MaxAttempts: 3}, attempt: 1, err: nil, expected: false},
+		{name: "Retryable", policy: &RetryPolicy{MaxAttempts: 3}, attempt: 2, err: errBoom, expected: true},
+		{name: "Spent", policy: &RetryPolicy{MaxAttempts: 3}, attempt: 3, err: errBoom, expected: false},
+		{name: "Cancelled", policy: &RetryPolicy{MaxAttempts: 3}, attempt: 1, err: context.Canceled, expected: false},
+		{name: "DeadlineExceeded", policy: &RetryPolicy{MaxAttempts: 3}, attempt: 1, err: context.DeadlineExceeded, expected: false},
+		{name: "Panic", policy: &RetryPolicy{MaxAttempts: 3}, attempt: 1, err: &PanicError{Value: "boom"}, expected: false},
+		{
+			name:     "PanicIgnoresRetryable",
+			policy:   &RetryPolicy{MaxAttempts: 3, Retryable: func(error) bool { return true }},
+			attempt:  1,
+			err:      &PanicError{Value: "boom"},
+			expected: false,
+		},
+		{
+			name:     "CustomRetryable",
+			policy:   &RetryPolicy{MaxAttempts: 3, Retryable: func(err error) bool { return !errors.Is(err, errBoom) }},
+			attempt:  1,
+			err:      errBoom,
+			expected: false,
+		},
+		{name: "ContextDone", policy: &RetryPolicy{MaxAttempts: 3}, ctx: cancelled, attempt: 1, err: errBoom, expected: false},
+		{
+			name:     "ContextDoneDuringBackoff",
+			policy:   &RetryPolicy{MaxAttempts: 3, Backoff: func(int) time.Duration { return time.Minute }},
+			ctx:      cancelled,
+			attempt:  1,
+			err:      errBoom,
+			expected: false,
+		},
+		{
+			name:     "WaitsOutBackoff",
+			policy:   &RetryPolicy{MaxAttempts: 3, Backoff: func(int) time.Duration { return time.Millisecond }},
+			attempt:  1,
+			err:      errBoom,
+			expected: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := testCase.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			proxy := &Proxy{Retry: testCase.policy}
+			if retry := proxy.ShouldRetry(ctx, testCase.attempt, testCase.err); retry != testCase.expected {
+				t.Errorf("expected %t, got %t", testCase.expected, retry)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Package slogx logs errors that cross the wrapper boundary with log/slog
+package slogx
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+
+	"github.com/CannibalVox/errproxy"
+)
+
+const (
+	// CategoryTransformed is the default category for errors the transformer replaced
+	CategoryTransformed = "transformed"
+	// CategoryPassthrough is the default category for errors the transformer returned untouched
+	CategoryPassthrough = "passthrough"
+	// CategorySuppressed is the default category for errors the transformer turned into nil
+	CategorySuppressed = "suppressed"
+)
+
+type Options struct {
+	// Categorize assigns a category to a failed call.  By default, calls are categorized by what
+	// the transformer did with the error.
+	Categorize func(call *errproxy.Call) string
+	// Level picks the level to log a failed call at.  By default, everything is logged at
+	// slog.LevelError.
+	Level func(call *errproxy.Call, category string) slog.Level
+	// SampleRate is the fraction of errors, from 0 to 1, that get logged.  Values of 0 or less log every
+	// error.
+	SampleRate float64
+	// LogArgs adds the call's arguments to each record, after running them through Redact
+	LogArgs bool
+	Redact  errproxy.Redactor
+}
+
+// Interceptor logs each call that returns an error.  Pass it as a Proxy's Interceptor, or combine it
+// with others using errproxy.Interceptors.
+type Interceptor struct {
+	logger  *slog.Logger
+	options Options
+}
+
+func New(logger *slog.Logger, options Options) *Interceptor {
+	if options.Categorize == nil {
+		options.Categorize = categorizeByTransform
+	}
+
+	return &Interceptor{
+		logger:  logger,
+		options: options,
+	}
+}
+
+func categorizeByTransform(call *errproxy.Call) string {
+	if call.Transformed == nil {
+		return CategorySuppressed
+	}
+
+	if call.Passthrough() {
+		return CategoryPassthrough
+	}
+
+	return CategoryTransformed
+}
+
+func (i *Interceptor) Before(call *errproxy.Call) {}
+
+func (i *Interceptor) After(call *errproxy.Call) {
+	if call.Err == nil {
+		return
+	}
+
+	if i.options.SampleRate > 0 && i.options.SampleRate < 1 && rand.Float64() >= i.options.SampleRate {
+		return
+	}
+
+	ctx := callContext(call)
+	category := i.options.Categorize(call)
+	level := slog.LevelError
+	if i.options.Level != nil {
+		level = i.options.Level(call, category)
+	}
+
+	if !i.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("wrapper", call.Method.Wrapper),
+		slog.String("type", call.Method.Type),
+		slog.String("method", call.Method.Name),
+		slog.Any("error", call.Err),
+		slog.Any("transformed", call.Transformed),
+		slog.String("category", category),
+		slog.Duration("duration", call.Duration),
+	}
+
+	if i.options.LogArgs {
+		attrs = append(attrs, slog.Any("args", call.RedactedArgs(i.options.Redact)))
+	}
+
+	i.logger.LogAttrs(ctx, level, "wrapped call failed", attrs...)
+}
+
+// callContext picks up the context passed to context-taking methods so handlers can read values
+// from it
+func callContext(call *errproxy.Call) context.Context {
+	if len(call.Args) > 0 {
+		ctx, isContext := call.Args[0].(context.Context)
+		if isContext && ctx != nil {
+			return ctx
+		}
+	}
+
+	return context.Background()
+}
@@ -0,0 +1,110 @@
+package errproxy
+
+import (
+	"errors"
+	"math/rand/v2"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// StackOptions bounds the cost of stack capture in wrappers generated with captureStacks
+type StackOptions struct {
+	// Depth is the maximum number of frames to capture
+	Depth int
+	// SampleRate is the fraction of errors, from 0 to 1, that get a stack attached
+	SampleRate float64
+}
+
+var stackOptions atomic.Pointer[StackOptions]
+
+func init() {
+	SetStackOptions(StackOptions{Depth: 32, SampleRate: 1})
+}
+
+// SetStackOptions changes stack capture for every generated wrapper in the process
+func SetStackOptions(options StackOptions) {
+	stackOptions.Store(&options)
+}
+
+// StackError carries the stack of the call site that received an error from a generated wrapper
+type StackError struct {
+	err error
+	pcs []uintptr
+}
+
+func (e *StackError) Error() string {
+	return e.err.Error()
+}
+
+func (e *StackError) Unwrap() error {
+	return e.err
+}
+
+// StackTrace returns the captured frames, starting from the caller of the wrapped method
+func (e *StackError) StackTrace() []runtime.Frame {
+	frames := make([]runtime.Frame, 0, len(e.pcs))
+	callers := runtime.CallersFrames(e.pcs)
+	for {
+		frame, more := callers.Next()
+		frames = append(frames, frame)
+		if !more {
+			break
+		}
+	}
+
+	return frames
+}
+
+// Stack renders the captured frames like a panic would
+func (e *StackError) Stack() string {
+	out := new(strings.Builder)
+	for _, frame := range e.StackTrace() {
+		out.WriteString(frame.Function)
+		out.WriteString("\n\t")
+		out.WriteString(frame.File)
+		out.WriteString(":")
+		out.WriteString(strconv.Itoa(frame.Line))
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+// WithStack attaches the stack of the generated method's caller to err, subject to the current
+// StackOptions.  Errors that already carry a stack are left alone, so errors passing through several
+// layers of wrappers keep the stack closest to where they came from.
+func WithStack(err error) error {
+	// Skip WithStack and the generated method
+	return withStack(err, 2)
+}
+
+func withStack(err error, skip int) error {
+	if err == nil {
+		return nil
+	}
+
+	options := stackOptions.Load()
+	if options.Depth <= 0 || options.SampleRate <= 0 {
+		return err
+	}
+
+	if options.SampleRate < 1 && rand.Float64() >= options.SampleRate {
+		return err
+	}
+
+	var existing *StackError
+	if errors.As(err, &existing) {
+		return err
+	}
+
+	// Skip runtime.Callers and withStack on top of the requested frames
+	pcs := make([]uintptr, options.Depth)
+	captured := runtime.Callers(skip+2, pcs)
+
+	return &StackError{
+		err: err,
+		pcs: pcs[:captured],
+	}
+}
@@ -0,0 +1,104 @@
+package errproxy
+
+import (
+	"encoding/json"
+	"expvar"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// MethodCounts is a snapshot of the counters for a single wrapped method
+type MethodCounts struct {
+	Calls  int64 `json:"calls"`
+	Errors int64 `json:"errors"`
+	// Transformed counts errors that the transformer replaced, Passthrough counts errors that it
+	// returned untouched
+	Transformed int64 `json:"transformed"`
+	Passthrough int64 `json:"passthrough"`
+}
+
+type methodCounters struct {
+	calls       atomic.Int64
+	errors      atomic.Int64
+	transformed atomic.Int64
+	passthrough atomic.Int64
+}
+
+// Stats is an Interceptor that counts calls and errors for each wrapped method in a tree.  It
+// implements expvar.Var, so it can be served from /debug/vars.
+type Stats struct {
+	methods sync.Map // *Method -> *methodCounters
+}
+
+// NewStats creates a Stats and publishes it to expvar under the provided name.  Like expvar.Publish,
+// it panics if the name is already in use, so give each wrapper tree its own name.
+func NewStats(name string) *Stats {
+	stats := &Stats{}
+	expvar.Publish(name, stats)
+	return stats
+}
+
+func (s *Stats) counters(method *Method) *methodCounters {
+	counters, ok := s.methods.Load(method)
+	if !ok {
+		counters, _ = s.methods.LoadOrStore(method, &methodCounters{})
+	}
+
+	return counters.(*methodCounters)
+}
+
+func (s *Stats) Before(call *Call) {
+	s.counters(call.Method).calls.Add(1)
+}
+
+func (s *Stats) After(call *Call) {
+	if call.Err == nil {
+		return
+	}
+
+	counters := s.counters(call.Method)
+	counters.errors.Add(1)
+	if call.Passthrough() {
+		counters.passthrough.Add(1)
+	} else {
+		counters.transformed.Add(1)
+	}
+}
+
+// Snapshot returns the current counts keyed by Wrapper.Method, such as "SqlDB.Query".  Wrapper names are
+// unique within a generated package, unlike type names, which can repeat across packages.
+func (s *Stats) Snapshot() map[string]MethodCounts {
+	snapshot := make(map[string]MethodCounts)
+	s.methods.Range(func(key, value interface{}) bool {
+		counters := value.(*methodCounters)
+		method := key.(*Method)
+		snapshot[method.Wrapper+"."+method.Name] = MethodCounts{
+			Calls:       counters.calls.Load(),
+			Errors:      counters.errors.Load(),
+			Transformed: counters.transformed.Load(),
+			Passthrough: counters.passthrough.Load(),
+		}
+		return true
+	})
+
+	return snapshot
+}
+
+// String renders the snapshot as JSON for expvar
+func (s *Stats) String() string {
+	text, err := json.Marshal(s.Snapshot())
+	if err != nil {
+		return "{}"
+	}
+
+	return string(text)
+}
+
+func sameError(err error, other error) bool {
+	if err == nil || other == nil {
+		return err == other
+	}
+
+	return reflect.TypeOf(err).Comparable() && err == other
+}
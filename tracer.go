@@ -0,0 +1,50 @@
+package errproxy
+
+// Span is a single traced call, as created by a Tracer
+type Span interface {
+	// RecordError is called with the transformed error when the call fails
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts a span for every call made through a wrapper tree.  It has no dependencies so it can
+// be adapted to any tracing library.  The parent is the span of the call that returned the wrapper
+// being called, so a Stmt.Query span is the child of the Tx.Prepare span that created the Stmt, which
+// is in turn the child of the DB.Begin span.  The parent is nil for calls on the root wrapper.
+type Tracer interface {
+	StartSpan(parent Span, call *Call) Span
+}
+
+// DeriveChild returns the Proxy a child wrapper returned from call should use.  It's Derive, with the
+// call's span set as the parent of every span started by the child.
+func (p *Proxy) DeriveChild(call *Call, childType string, method string) *Proxy {
+	child := p.Derive(childType, method)
+	if child == nil || call == nil || call.Span == nil {
+		return child
+	}
+
+	if child == p {
+		derived := *p
+		child = &derived
+	}
+
+	child.span = call.Span
+	return child
+}
+
+func (c *Call) startSpan() {
+	if c.proxy.Tracer != nil {
+		c.Span = c.proxy.Tracer.StartSpan(c.proxy.span, c)
+	}
+}
+
+func (c *Call) endSpan() {
+	if c.Span == nil {
+		return
+	}
+
+	if c.Transformed != nil {
+		c.Span.RecordError(c.Transformed)
+	}
+	c.Span.End()
+}
@@ -0,0 +1,196 @@
+package types
+
+import (
+	"fmt"
+	gotypes "go/types"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// anonUsage is a place an anonymous type is returned from
+type anonUsage struct {
+	Key     string // Stable location of the usage, such as "github.com/go-redis/redis/v8.Client.Pipeline:0"
+	Pattern string // The usage as a config pattern, such as "Client.Pipeline"
+	Name    string // The name derived from the usage, such as "ClientPipelineResult"
+}
+
+// LockedName is an entry in the naming lockfile, which keeps the names of anonymous wrappers stable
+// when the library they come from changes
+type LockedName struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Usages []string `json:"usages"`
+}
+
+func isAnonymous(t gotypes.Type) bool {
+	switch t.(type) {
+	case *gotypes.Struct, *gotypes.Interface:
+		return true
+	}
+
+	return false
+}
+
+// usageName is the name a type contributes to the names of anonymous types returned from its methods
+func (t *TypeDB) usageName(typeInfo *TypeInfo) (key string, name string) {
+	root := typeInfo.RootType.RootType
+	if named, isNamed := root.Type.(*gotypes.Named); isNamed {
+		return root.TypeKey, named.Obj().Name()
+	}
+
+	usages := t.anonUsages[root.TypeKey]
+	if len(usages) > 0 {
+		return usages[0].Key, usages[0].Name
+	}
+
+	return root.TypeKey, root.WrapperTypeName()
+}
+
+// recordUsage notes that an anonymous type, or a type built from one, is returned from a method, so
+// it can be named after the method later
+func (t *TypeDB) recordUsage(resultType gotypes.Type, owner *TypeInfo, method string, resultIndex int, resultCount int) {
+	for _, elementType := range elementTypes(resultType) {
+		t.recordUsage(elementType, owner, method, resultIndex, resultCount)
+	}
+
+	if !isAnonymous(resultType) {
+		return
+	}
+
+	ownerKey, ownerName := t.usageName(owner)
+	suffix := "Result"
+	if resultCount > 1 {
+		suffix = fmt.Sprintf("Result%d", resultIndex+1)
+	}
+
+	typeKey := gotypes.TypeString(resultType, nil)
+	key := fmt.Sprintf("%s.%s:%d", ownerKey, method, resultIndex)
+	for _, usage := range t.anonUsages[typeKey] {
+		if usage.Key == key {
+			return
+		}
+	}
+
+	t.anonUsages[typeKey] = append(t.anonUsages[typeKey], anonUsage{
+		Key:     key,
+		Pattern: fmt.Sprintf("%s.%s", ownerName, method),
+		Name:    ownerName + method + suffix,
+	})
+}
+
+// ResolveAnonymousNames replaces the hash-based names of anonymous wrappers with names derived from
+// where they're returned, such as ClientPipelineResult for an interface returned from Client.Pipeline.
+// Names come from, in order of preference:
+//
+//   - overrides, keyed by a usage pattern such as "Client.Pipeline"
+//   - the lockfile entry for the same type, or for a type returned from the same place
+//   - the first place the type is returned from
+//
+// The returned entries should be written back to the lockfile.
+func (t *TypeDB) ResolveAnonymousNames(overrides map[string]string, locked []LockedName) []LockedName {
+	taken := make(map[string]bool)
+	var roots []*RootTypeInfo
+	_ = t.WalkRootTypes(func(root *RootTypeInfo) error {
+		if isAnonymous(root.RootType.Type) {
+			roots = append(roots, root)
+		} else {
+			taken[strings.ToLower(root.RootType.WrapperTypeName())] = true
+		}
+		return nil
+	})
+
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].RootType.TypeKey < roots[j].RootType.TypeKey
+	})
+
+	lockedByType := make(map[string]string)
+	lockedByUsage := make(map[string]string)
+	for _, entry := range locked {
+		lockedByType[entry.Type] = entry.Name
+		for _, usage := range entry.Usages {
+			lockedByUsage[usage] = entry.Name
+		}
+	}
+
+	// Names from overrides and the lockfile are claimed first, so derived names can't take them
+	names := make(map[*RootTypeInfo]string)
+	for _, preferLocked := range []bool{true, false} {
+		for _, root := range roots {
+			if names[root] != "" {
+				continue
+			}
+
+			name := t.anonName(root, overrides, lockedByType, lockedByUsage, preferLocked)
+			if name == "" {
+				continue
+			}
+
+			unique := name
+			for i := 2; taken[strings.ToLower(unique)]; i++ {
+				unique = fmt.Sprintf("%s%d", name, i)
+			}
+
+			taken[strings.ToLower(unique)] = true
+			names[root] = unique
+		}
+	}
+
+	lock := make([]LockedName, 0, len(roots))
+	for _, root := range roots {
+		t.renameRoot(root, names[root], snakeCase(names[root])+".go")
+
+		entry := LockedName{Name: names[root], Type: root.RootType.TypeKey}
+		for _, usage := range t.anonUsages[root.RootType.TypeKey] {
+			entry.Usages = append(entry.Usages, usage.Key)
+		}
+		lock = append(lock, entry)
+	}
+
+	return lock
+}
+
+// anonName picks the name for an anonymous root type.  On the first pass, it only returns overridden
+// and locked names.
+func (t *TypeDB) anonName(root *RootTypeInfo, overrides map[string]string, lockedByType map[string]string, lockedByUsage map[string]string, firstPass bool) string {
+	usages := t.anonUsages[root.RootType.TypeKey]
+	for _, usage := range usages {
+		if name := overrides[usage.Pattern]; name != "" {
+			return name
+		}
+	}
+
+	if name := lockedByType[root.RootType.TypeKey]; name != "" {
+		return name
+	}
+
+	for _, usage := range usages {
+		if name := lockedByUsage[usage.Key]; name != "" {
+			return name
+		}
+	}
+
+	if firstPass {
+		return ""
+	}
+
+	if len(usages) > 0 {
+		return usages[0].Name
+	}
+
+	return root.RootType.WrapperTypeName()
+}
+
+// snakeCase turns a wrapper name like ClientPipelineResult into client_pipeline_result
+func snakeCase(name string) string {
+	out := new(strings.Builder)
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			out.WriteRune('_')
+		}
+		out.WriteRune(unicode.ToLower(r))
+	}
+
+	return out.String()
+}
@@ -0,0 +1,182 @@
+package types
+
+import (
+	"go/token"
+	gotypes "go/types"
+	"reflect"
+	"testing"
+)
+
+// newAnonInterface builds an anonymous interface whose methods each return an error
+func newAnonInterface(pkg *gotypes.Package, methods ...string) *gotypes.Interface {
+	results := gotypes.NewTuple(gotypes.NewVar(token.NoPos, pkg, "", errorType))
+
+	var funcs []*gotypes.Func
+	for _, method := range methods {
+		sig := gotypes.NewSignatureType(nil, nil, nil, nil, results, false)
+		funcs = append(funcs, gotypes.NewFunc(token.NoPos, pkg, method, sig))
+	}
+
+	return gotypes.NewInterfaceType(funcs, nil).Complete()
+}
+
+// clientDB builds a TypeDB holding a redis.Client whose Pipeline method returns pipeline, and whose Pair
+// method returns both of pair.  The anonymous types are added by hand, since the walker doesn't wrap them.
+func clientDB(pipeline gotypes.Type, pair [2]gotypes.Type) *TypeDB {
+	pkg := gotypes.NewPackage("example.com/redis", "redis")
+	client := gotypes.NewPointer(newNamed(pkg, "Client"))
+
+	db := newTypeDB()
+	owner := db.AddType(client, nil)
+	owner.Status = WrapStatusHard
+
+	results := []struct {
+		resultType gotypes.Type
+		method     string
+		index      int
+		count      int
+	}{
+		{pipeline, "Pipeline", 0, 1},
+		{pair[0], "Pair", 0, 2},
+		{pair[1], "Pair", 1, 2},
+	}
+
+	for _, result := range results {
+		db.AddType(result.resultType, client).Status = WrapStatusSoft
+		db.recordUsage(result.resultType, owner, result.method, result.index, result.count)
+	}
+
+	return db
+}
+
+func TestResolveAnonymousNames(t *testing.T) {
+	pkg := gotypes.NewPackage("example.com/redis", "redis")
+	pipeline := newAnonInterface(pkg, "Exec")
+	changedPipeline := newAnonInterface(pkg, "Exec", "Discard")
+	pair := [2]gotypes.Type{newAnonInterface(pkg, "First"), newAnonInterface(pkg, "Second")}
+
+	testCases := []struct {
+		name          string
+		pipeline      gotypes.Type
+		overrides     map[string]string
+		locked        []LockedName
+		expectedName  string
+		expectedFile  string
+		expectedPairs [2]string
+	}{
+		{
+			name:          "Derived",
+			pipeline:      pipeline,
+			expectedName:  "ClientPipelineResult",
+			expectedFile:  "client_pipeline_result.go",
+			expectedPairs: [2]string{"ClientPairResult1", "ClientPairResult2"},
+		},
+		{
+			name:          "Override",
+			pipeline:      pipeline,
+			overrides:     map[string]string{"Client.Pipeline": "Pipeline"},
+			expectedName:  "Pipeline",
+			expectedFile:  "pipeline.go",
+			expectedPairs: [2]string{"ClientPairResult1", "ClientPairResult2"},
+		},
+		{
+			name:          "LockedByType",
+			pipeline:      pipeline,
+			locked:        []LockedName{{Name: "Pipe", Type: gotypes.TypeString(pipeline, nil)}},
+			expectedName:  "Pipe",
+			expectedFile:  "pipe.go",
+			expectedPairs: [2]string{"ClientPairResult1", "ClientPairResult2"},
+		},
+		{
+			name:          "LockedByUsage",
+			pipeline:      changedPipeline,
+			locked:        []LockedName{{Name: "Pipe", Type: gotypes.TypeString(pipeline, nil), Usages: []string{"example.com/redis.Client.Pipeline:0"}}},
+			expectedName:  "Pipe",
+			expectedFile:  "pipe.go",
+			expectedPairs: [2]string{"ClientPairResult1", "ClientPairResult2"},
+		},
+		{
+			name:          "OverrideBeatsLock",
+			pipeline:      pipeline,
+			overrides:     map[string]string{"Client.Pipeline": "Pipeline"},
+			locked:        []LockedName{{Name: "Pipe", Type: gotypes.TypeString(pipeline, nil)}},
+			expectedName:  "Pipeline",
+			expectedFile:  "pipeline.go",
+			expectedPairs: [2]string{"ClientPairResult1", "ClientPairResult2"},
+		},
+		{
+			name:          "TakenByNamedWrapper",
+			pipeline:      pipeline,
+			overrides:     map[string]string{"Client.Pipeline": "RedisClient"},
+			expectedName:  "RedisClient2",
+			expectedFile:  "redis_client2.go",
+			expectedPairs: [2]string{"ClientPairResult1", "ClientPairResult2"},
+		},
+		{
+			name:          "TakenByLock",
+			pipeline:      pipeline,
+			locked:        []LockedName{{Name: "ClientPipelineResult", Type: gotypes.TypeString(pair[0], nil)}},
+			expectedName:  "ClientPipelineResult2",
+			expectedFile:  "client_pipeline_result2.go",
+			expectedPairs: [2]string{"ClientPipelineResult", "ClientPairResult2"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			db := clientDB(testCase.pipeline, pair)
+			lock := db.ResolveAnonymousNames(testCase.overrides, testCase.locked)
+
+			typeInfo := db.LocateTypeInfo(testCase.pipeline)
+			if name := typeInfo.TypeId.WrapperTypeName(); name != testCase.expectedName {
+				t.Errorf("expected wrapper %s, got %s", testCase.expectedName, name)
+			}
+
+			if file := typeInfo.TypeId.TypeFileName(); file != testCase.expectedFile {
+				t.Errorf("expected file %s, got %s", testCase.expectedFile, file)
+			}
+
+			for i, pairType := range pair {
+				if name := db.LocateTypeInfo(pairType).TypeId.WrapperTypeName(); name != testCase.expectedPairs[i] {
+					t.Errorf("expected wrapper %s, got %s", testCase.expectedPairs[i], name)
+				}
+			}
+
+			expectedEntry := LockedName{
+				Name:   testCase.expectedName,
+				Type:   gotypes.TypeString(testCase.pipeline, nil),
+				Usages: []string{"example.com/redis.Client.Pipeline:0"},
+			}
+			found := false
+			for _, entry := range lock {
+				if entry.Type == expectedEntry.Type {
+					found = true
+					if !reflect.DeepEqual(entry, expectedEntry) {
+						t.Errorf("expected lock entry %+v, got %+v", expectedEntry, entry)
+					}
+				}
+			}
+
+			if len(lock) != 3 || !found {
+				t.Errorf("expected a lock entry for each anonymous wrapper, got %+v", lock)
+			}
+		})
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	testCases := map[string]string{
+		"ClientPipelineResult": "client_pipeline_result",
+		"ClientPairResult2":    "client_pair_result2",
+		"HTTPClient":           "http_client",
+		"RedisV7Client":        "redis_v7_client",
+		"Pipeline":             "pipeline",
+		"ClientGetURLResult":   "client_get_url_result",
+	}
+
+	for name, expected := range testCases {
+		if actual := snakeCase(name); actual != expected {
+			t.Errorf("snakeCase(%q): expected %q, got %q", name, expected, actual)
+		}
+	}
+}
@@ -6,6 +6,7 @@ type TypeDB struct {
 	typesByKey  map[string]*TypeInfo
 	typesByRoot map[string]*RootTypeInfo
 	dependents  map[string]map[string]bool
+	anonUsages  map[string][]anonUsage
 }
 
 func newTypeDB() *TypeDB {
@@ -13,6 +14,7 @@ func newTypeDB() *TypeDB {
 		typesByKey:  make(map[string]*TypeInfo),
 		typesByRoot: make(map[string]*RootTypeInfo),
 		dependents:  make(map[string]map[string]bool),
+		anonUsages:  make(map[string][]anonUsage),
 	}
 }
 
@@ -29,6 +29,8 @@ type TypeIdentifier struct {
 	Mode         TypeMode
 	PointerDepth int // Only used for structs- how many *'s on this type?
 	Type         gotypes.Type
+	WrapperName  string // Overrides the wrapper type name, when the usual name is taken
+	FileName     string // Overrides the generated file name along with WrapperName
 }
 
 func (t *TypeIdentifier) String() string {
@@ -53,6 +55,10 @@ func (t *TypeIdentifier) String() string {
 }
 
 func (t TypeIdentifier) TypeFileName() string {
+	if t.FileName != "" {
+		return t.FileName
+	}
+
 	rootType := rootType(t.Type)
 	namedRoot, isNamed := rootType.(*gotypes.Named)
 	if isNamed {
@@ -63,6 +69,10 @@ func (t TypeIdentifier) TypeFileName() string {
 }
 
 func (t TypeIdentifier) WrapperTypeName() string {
+	if t.WrapperName != "" {
+		return t.WrapperName
+	}
+
 	rootType := rootType(t.Type)
 	namedRoot, isNamed := rootType.(*gotypes.Named)
 	if isNamed {
@@ -72,6 +82,38 @@ func (t TypeIdentifier) WrapperTypeName() string {
 	return fmt.Sprintf("Anon%s", hashFromType(rootType))
 }
 
+// SourceTypeName is the unqualified name of the wrapped type, used when matching methods against
+// config patterns.  Anonymous types use their wrapper name.
+func (t TypeIdentifier) SourceTypeName() string {
+	namedRoot, isNamed := rootType(t.Type).(*gotypes.Named)
+	if isNamed {
+		return namedRoot.Obj().Name()
+	}
+
+	return t.WrapperTypeName()
+}
+
+// SourcePackagePath is the import path of the wrapped type's package, or empty for anonymous types
+func (t TypeIdentifier) SourcePackagePath() string {
+	namedRoot, isNamed := rootType(t.Type).(*gotypes.Named)
+	if isNamed {
+		return namedRoot.Obj().Pkg().Path()
+	}
+
+	return ""
+}
+
+// QualifiedSourceName is the package-qualified name of the wrapped type, such as "redis.Client".
+// Anonymous types use their wrapper name.
+func (t TypeIdentifier) QualifiedSourceName() string {
+	namedRoot, isNamed := rootType(t.Type).(*gotypes.Named)
+	if isNamed {
+		return fmt.Sprintf("%s.%s", namedRoot.Obj().Pkg().Name(), namedRoot.Obj().Name())
+	}
+
+	return t.WrapperTypeName()
+}
+
 func (t TypeIdentifier) WrapFuncName() string {
 	rootName := fmt.Sprintf("Wrap%s", t.WrapperTypeName())
 	if t.Mode == TypeStruct {
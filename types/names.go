@@ -0,0 +1,164 @@
+package types
+
+import (
+	"fmt"
+	gotypes "go/types"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Rename records a wrapper that was given a different name than usual to avoid colliding with another
+type Rename struct {
+	Type     string // The qualified source type, such as "github.com/go-redis/redis/v8.Client"
+	Original string // The wrapper name it would normally have had
+	Wrapper  string // The wrapper name it was given
+}
+
+func (r Rename) String() string {
+	return fmt.Sprintf("%s is wrapped as %s instead of %s", r.Type, r.Wrapper, r.Original)
+}
+
+// ResolveNameCollisions gives distinct names to wrappers that would otherwise share a wrapper type,
+// Wrap function and file name.  That happens when named types from packages with the same name are
+// wrapped together, such as two "types" packages, or v7 and v8 of the same module.  Colliding wrappers
+// are named after the trailing elements of their package paths, using as few as it takes to tell them
+// apart, so ".../go-redis/redis/v8".Client becomes RedisV8Client.  The renames are returned so they can be
+// reported.  Wrappers whose whole package paths give the same name, such as ones from go-redis and
+// go_redis, are an error rather than one overwriting the other.
+func (t *TypeDB) ResolveNameCollisions() ([]Rename, error) {
+	// Find wrapped root types that share a name, ignoring case since file names are lower case
+	taken := make(map[string]bool)
+	byName := make(map[string][]*RootTypeInfo)
+	_ = t.WalkRootTypes(func(root *RootTypeInfo) error {
+		name := strings.ToLower(root.RootType.WrapperTypeName())
+		byName[name] = append(byName[name], root)
+		taken[name] = true
+		return nil
+	})
+
+	var renames []Rename
+nextGroup:
+	for _, roots := range byName {
+		if len(roots) < 2 {
+			continue
+		}
+
+		for _, root := range roots {
+			if _, isNamed := root.RootType.Type.(*gotypes.Named); !isNamed {
+				// ResolveAnonymousNames already gave anonymous wrappers names that don't collide
+				continue nextGroup
+			}
+		}
+
+		sort.Slice(roots, func(i, j int) bool {
+			return roots[i].RootType.TypeKey < roots[j].RootType.TypeKey
+		})
+
+		names, err := distinctNames(roots, taken)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, root := range roots {
+			original := root.RootType.WrapperTypeName()
+			t.renameRoot(root, names[i].wrapper, names[i].file)
+			renames = append(renames, Rename{
+				Type:     root.RootType.TypeKey,
+				Original: original,
+				Wrapper:  names[i].wrapper,
+			})
+		}
+	}
+
+	sort.Slice(renames, func(i, j int) bool {
+		return renames[i].Type < renames[j].Type
+	})
+
+	return renames, nil
+}
+
+type wrapperName struct {
+	wrapper string
+	file    string
+}
+
+// distinctNames names each root after enough trailing elements of its package path to make every name
+// unique, both within the group and against the names already taken.  It fails if the whole paths
+// still don't tell them apart.
+func distinctNames(roots []*RootTypeInfo, taken map[string]bool) ([]wrapperName, error) {
+	names := make([]wrapperName, len(roots))
+	for depth := 2; ; depth++ {
+		seen := make(map[string]bool)
+		unique := true
+		exhausted := true
+		for i, root := range roots {
+			typeName := root.RootType.Type.(*gotypes.Named).Obj()
+			elements := strings.Split(typeName.Pkg().Path(), "/")
+			if depth < len(elements) {
+				exhausted = false
+			}
+			elements = elements[len(elements)-min(depth, len(elements)):]
+
+			names[i] = pathName(elements, typeName.Name())
+			key := strings.ToLower(names[i].wrapper)
+			if seen[key] || taken[key] {
+				unique = false
+			}
+			seen[key] = true
+		}
+
+		if !unique && exhausted {
+			var typeKeys []string
+			for _, root := range roots {
+				typeKeys = append(typeKeys, root.RootType.TypeKey)
+			}
+			return nil, fmt.Errorf("can't give distinct wrapper names to %s, since their package paths give the same names", strings.Join(typeKeys, ", "))
+		}
+
+		if unique {
+			for _, name := range names {
+				taken[strings.ToLower(name.wrapper)] = true
+			}
+
+			return names, nil
+		}
+	}
+}
+
+// pathName builds a wrapper and file name from package path elements and a type name, such as
+// RedisV8Client and redis_v8_client.go
+func pathName(elements []string, typeName string) wrapperName {
+	var words []string
+	for _, element := range elements {
+		words = append(words, strings.FieldsFunc(element, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})...)
+	}
+
+	wrapper := new(strings.Builder)
+	for _, word := range words {
+		wrapper.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	wrapper.WriteString(typeName)
+
+	name := wrapper.String()
+	if !unicode.IsLetter([]rune(name)[0]) {
+		name = "Pkg" + name
+	}
+
+	file := strings.ToLower(strings.Join(append(words, typeName), "_")) + ".go"
+	return wrapperName{wrapper: name, file: file}
+}
+
+// renameRoot sets the wrapper and file name of a root type, and of every type built on it
+func (t *TypeDB) renameRoot(root *RootTypeInfo, wrapper string, file string) {
+	root.RootType.WrapperName = wrapper
+	root.RootType.FileName = file
+	for _, typeInfo := range t.typesByKey {
+		if typeInfo.RootType == root {
+			typeInfo.TypeId.WrapperName = wrapper
+			typeInfo.TypeId.FileName = file
+		}
+	}
+}
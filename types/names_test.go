@@ -0,0 +1,140 @@
+package types
+
+import (
+	"go/token"
+	gotypes "go/types"
+	gopath "path"
+	"strings"
+	"testing"
+)
+
+func newNamed(pkg *gotypes.Package, name string) *gotypes.Named {
+	obj := gotypes.NewTypeName(token.NoPos, pkg, name, nil)
+	named := gotypes.NewNamed(obj, gotypes.NewStruct(nil, nil), nil)
+	pkg.Scope().Insert(obj)
+	return named
+}
+
+// addMethod adds a method to a named type that returns results, followed by an error
+func addMethod(named *gotypes.Named, name string, results ...gotypes.Type) {
+	pkg := named.Obj().Pkg()
+	vars := []*gotypes.Var{}
+	for _, result := range append(results, errorType) {
+		vars = append(vars, gotypes.NewVar(token.NoPos, pkg, "", result))
+	}
+
+	recv := gotypes.NewVar(token.NoPos, pkg, "r", gotypes.NewPointer(named))
+	sig := gotypes.NewSignatureType(recv, nil, nil, nil, gotypes.NewTuple(vars...), false)
+	named.AddMethod(gotypes.NewFunc(token.NoPos, pkg, name, sig))
+}
+
+// walkRoot builds a TypeDB from a root type whose methods return each of the types
+func walkRoot(t *testing.T, returned ...*gotypes.Named) (*TypeDB, *gotypes.Named) {
+	t.Helper()
+
+	rootPkg := gotypes.NewPackage("example.com/root", "root")
+	root := newNamed(rootPkg, "Root")
+	packages := []string{rootPkg.Path()}
+	for i, named := range returned {
+		addMethod(named, "Do")
+		addMethod(root, string(rune('A'+i)), gotypes.NewPointer(named))
+		packages = append(packages, named.Obj().Pkg().Path())
+	}
+
+	walker := NewTypeWalker(packages)
+	walker.QueueType(gotypes.NewPointer(root), nil)
+	return walker.WalkTypes(), root
+}
+
+func TestResolveNameCollisions(t *testing.T) {
+	testCases := []struct {
+		name            string
+		paths           []string
+		typeName        string
+		expectedNames   []string
+		expectedFiles   []string
+		expectedRenames int
+	}{
+		{
+			name:          "NoCollision",
+			paths:         []string{"example.com/redis", "example.com/sql"},
+			typeName:      "Client",
+			expectedNames: []string{"RedisClient", "SqlClient"},
+			expectedFiles: []string{"redis_client.go", "sql_client.go"},
+		},
+		{
+			name:            "SameName",
+			paths:           []string{"example.com/a/types", "example.com/b/types"},
+			typeName:        "Thing",
+			expectedNames:   []string{"ATypesThing", "BTypesThing"},
+			expectedFiles:   []string{"a_types_thing.go", "b_types_thing.go"},
+			expectedRenames: 2,
+		},
+		{
+			name:            "MajorVersions",
+			paths:           []string{"github.com/go-redis/redis/v7", "github.com/go-redis/redis/v8"},
+			typeName:        "Client",
+			expectedNames:   []string{"RedisV7Client", "RedisV8Client"},
+			expectedFiles:   []string{"redis_v7_client.go", "redis_v8_client.go"},
+			expectedRenames: 2,
+		},
+		{
+			name:            "SharedParent",
+			paths:           []string{"example.com/x/internal/redis", "example.com/y/internal/redis"},
+			typeName:        "Client",
+			expectedNames:   []string{"XInternalRedisClient", "YInternalRedisClient"},
+			expectedFiles:   []string{"x_internal_redis_client.go", "y_internal_redis_client.go"},
+			expectedRenames: 2,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var returned []*gotypes.Named
+			for _, path := range testCase.paths {
+				pkgName := gopath.Base(path)
+				if strings.HasPrefix(pkgName, "v") && len(pkgName) > 1 {
+					pkgName = gopath.Base(gopath.Dir(path))
+				}
+				returned = append(returned, newNamed(gotypes.NewPackage(path, pkgName), testCase.typeName))
+			}
+
+			db, _ := walkRoot(t, returned...)
+			renames, err := db.ResolveNameCollisions()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(renames) != testCase.expectedRenames {
+				t.Errorf("expected %d renames, got %v", testCase.expectedRenames, renames)
+			}
+
+			for i, named := range returned {
+				typeInfo := db.LocateTypeInfo(gotypes.NewPointer(named))
+				if name := typeInfo.TypeId.WrapperTypeName(); name != testCase.expectedNames[i] {
+					t.Errorf("expected wrapper %s, got %s", testCase.expectedNames[i], name)
+				}
+
+				if file := typeInfo.TypeId.TypeFileName(); file != testCase.expectedFiles[i] {
+					t.Errorf("expected file %s, got %s", testCase.expectedFiles[i], file)
+				}
+
+				if root := typeInfo.RootType.RootType.WrapperTypeName(); root != testCase.expectedNames[i] {
+					t.Errorf("expected root wrapper %s, got %s", testCase.expectedNames[i], root)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveNameCollisionsExhausted(t *testing.T) {
+	db, _ := walkRoot(t,
+		newNamed(gotypes.NewPackage("example.com/go-redis/redis", "redis"), "Client"),
+		newNamed(gotypes.NewPackage("example.com/go_redis/redis", "redis"), "Client"),
+	)
+
+	renames, err := db.ResolveNameCollisions()
+	if err == nil {
+		t.Errorf("expected an error for package paths that give the same names, got renames %v", renames)
+	}
+}
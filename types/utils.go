@@ -84,3 +84,104 @@ var errorType = gotypes.Universe.Lookup("error").Type()
 func IsError(t gotypes.Type) bool {
 	return gotypes.Identical(t, errorType)
 }
+
+func IsContext(t gotypes.Type) bool {
+	named, isNamed := t.(*gotypes.Named)
+	if !isNamed || named.Obj().Pkg() == nil {
+		return false
+	}
+
+	return named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
+
+// IsCloser reports whether a type, or a pointer to it, has a Close() error method
+func IsCloser(t gotypes.Type) bool {
+	methodSet := gotypes.NewMethodSet(t)
+	if _, isPointer := t.(*gotypes.Pointer); !isPointer && !gotypes.IsInterface(t) {
+		methodSet = gotypes.NewMethodSet(gotypes.NewPointer(t))
+	}
+
+	closeMethod := methodSet.Lookup(nil, "Close")
+	if closeMethod == nil {
+		return false
+	}
+
+	sig, ok := closeMethod.Type().(*gotypes.Signature)
+	return ok && sig.Params().Len() == 0 && sig.Results().Len() == 1 && IsError(sig.Results().At(0).Type())
+}
+
+// CarriesError reports whether a type is a pointer to a command that holds its own error, like
+// go-redis's *StringCmd, which has a SetErr(error) method
+func CarriesError(t gotypes.Type) bool {
+	pointer, isPointer := t.(*gotypes.Pointer)
+	if !isPointer {
+		return false
+	}
+
+	if _, isNamed := pointer.Elem().(*gotypes.Named); !isNamed {
+		return false
+	}
+
+	setErr := gotypes.NewMethodSet(t).Lookup(nil, "SetErr")
+	if setErr == nil {
+		return false
+	}
+
+	sig, ok := setErr.Type().(*gotypes.Signature)
+	return ok && sig.Params().Len() == 1 && IsError(sig.Params().At(0).Type()) && sig.Results().Len() == 0
+}
+
+// releaseMethods are the methods that end a result's use of the context it was created with, like
+// sql.Rows.Close and sql.Tx.Commit
+var releaseMethods = []string{"Close", "Commit", "Rollback"}
+
+// HoldsContext reports whether a result type keeps using the context of the call that returned it,
+// which is assumed when it, or one of its fields, has a Close, Commit or Rollback method.  Fields are
+// checked so that types like sql.Row, which hold a sql.Rows until they're scanned, are caught.
+func HoldsContext(t gotypes.Type) bool {
+	return holdsContext(t, make(map[gotypes.Type]bool), 1)
+}
+
+func holdsContext(t gotypes.Type, visited map[gotypes.Type]bool, fieldDepth int) bool {
+	for {
+		pointer, isPointer := t.(*gotypes.Pointer)
+		if !isPointer {
+			break
+		}
+		t = pointer.Elem()
+	}
+
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+
+	methodSet := gotypes.NewMethodSet(t)
+	if !gotypes.IsInterface(t) {
+		methodSet = gotypes.NewMethodSet(gotypes.NewPointer(t))
+	}
+
+	for _, method := range releaseMethods {
+		if methodSet.Lookup(nil, method) != nil {
+			return true
+		}
+	}
+
+	structType, isStruct := t.Underlying().(*gotypes.Struct)
+	if !isStruct {
+		return false
+	}
+
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		if field.Embedded() {
+			if holdsContext(field.Type(), visited, fieldDepth) {
+				return true
+			}
+		} else if fieldDepth > 0 && holdsContext(field.Type(), visited, fieldDepth-1) {
+			return true
+		}
+	}
+
+	return false
+}
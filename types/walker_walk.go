@@ -64,6 +64,7 @@ func (state *TypeWalker) walkSingleType(walkType *TypeInfo) {
 				}
 			} else {
 				state.QueueType(returnType, walkType.TypeId.Type)
+				state.typeDB.recordUsage(returnType, walkType, wrappedMethod.Obj().Name(), i, nonErrorResults(sig))
 			}
 		}
 	}
@@ -80,3 +81,14 @@ func (state *TypeWalker) WalkTypes() *TypeDB {
 
 	return state.typeDB
 }
+
+func nonErrorResults(sig *gotypes.Signature) int {
+	count := 0
+	for i := 0; i < sig.Results().Len(); i++ {
+		if !IsError(sig.Results().At(i).Type()) {
+			count++
+		}
+	}
+
+	return count
+}